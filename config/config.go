@@ -1,44 +1,411 @@
 package config
 
 import (
+	"TwitterMonitor/internal/utils"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
 	"os"
-	"strconv"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
 )
 
+// Config is the application configuration. Every setting has a default, which is overridden in
+// turn by the config file, environment variables and command-line flags
 type Config struct {
-	DatabaseURL string
-	ServerPort  int
-	Environment string
+	Database DatabaseConfig `mapstructure:"database"`
+	Server   ServerConfig   `mapstructure:"server"`
+	Twitter  TwitterConfig  `mapstructure:"twitter"`
+	Market   MarketConfig   `mapstructure:"market"`
+	Channels ChannelsConfig `mapstructure:"channels"`
+	Jobs     JobsConfig     `mapstructure:"jobs"`
+	Log      LogConfig      `mapstructure:"log"`
+	Features FeaturesConfig `mapstructure:"features"`
+	Health   HealthConfig   `mapstructure:"health"`
+	Tracing  TracingConfig  `mapstructure:"tracing"`
+}
+
+// DatabaseConfig selects the store, see database.Open for the URL forms
+type DatabaseConfig struct {
+	URL string `mapstructure:"url"`
+	// ReadTimeout and WriteTimeout bound each query and each change, 0 for no limit
+	ReadTimeout  time.Duration `mapstructure:"read_timeout"`
+	WriteTimeout time.Duration `mapstructure:"write_timeout"`
+}
+
+// ServerConfig configures the HTTP server
+type ServerConfig struct {
+	Port        int    `mapstructure:"port"`
+	Environment string `mapstructure:"environment"`
+	// ShutdownTimeout bounds draining requests and stopping background jobs on shutdown
+	ShutdownTimeout time.Duration `mapstructure:"shutdown_timeout"`
+	// AdminToken is the bearer token required by the /admin endpoints, which are disabled
+	// while it is empty
+	AdminToken string `mapstructure:"admin_token"`
+	// IngestToken is the bearer token the crawler sends to the twitter ingest and delete
+	// endpoints, which are disabled while it is empty
+	IngestToken string `mapstructure:"ingest_token"`
+}
+
+// TwitterConfig configures the client of the tw_user_info service
+type TwitterConfig struct {
+	BaseURL string        `mapstructure:"base_url"`
+	Token   string        `mapstructure:"token"`
+	Timeout time.Duration `mapstructure:"timeout"`
+}
+
+// MarketConfig configures the market info API shown next to channel content
+type MarketConfig struct {
+	BaseURL string        `mapstructure:"base_url"`
+	Timeout time.Duration `mapstructure:"timeout"`
+}
+
+// ChannelsConfig holds the limits applied to channels and their listings
+type ChannelsConfig struct {
+	WatchlistLimit       int `mapstructure:"watchlist_limit"`
+	PageSize             int `mapstructure:"page_size"`
+	RecentFollowersLimit int `mapstructure:"recent_followers_limit"`
+	// RestoreDays is how long a deleted channel can be restored before it is purged
+	RestoreDays int `mapstructure:"restore_days"`
+}
+
+// RestoreGrace is how long a deleted channel can be restored
+func (c ChannelsConfig) RestoreGrace() time.Duration {
+	return time.Duration(c.RestoreDays) * 24 * time.Hour
+}
+
+// JobsConfig configures the background jobs
+type JobsConfig struct {
+	ReconcileFix bool `mapstructure:"reconcile_fix"`
+}
+
+// LogConfig configures logging
+type LogConfig struct {
+	// Level is the lowest level logged: debug, info, warn or error
+	Level string `mapstructure:"level"`
+	// Format is text or json
+	Format string `mapstructure:"format"`
+}
+
+// HealthConfig configures the readiness checks
+type HealthConfig struct {
+	// Timeout bounds each dependency check of /readyz
+	Timeout time.Duration `mapstructure:"timeout"`
+}
+
+// Trace exporters
+const (
+	TracingExporterNone   = "none"
+	TracingExporterStdout = "stdout"
+	TracingExporterOTLP   = "otlp"
+)
+
+// TracingConfig configures the export of OpenTelemetry traces
+type TracingConfig struct {
+	// Exporter is none, stdout or otlp
+	Exporter string `mapstructure:"exporter"`
+	// Endpoint is the URL of the OTLP/HTTP collector the otlp exporter sends to
+	Endpoint string `mapstructure:"endpoint"`
+}
+
+// FeaturesConfig holds feature toggles
+type FeaturesConfig struct {
+	// MarketInfo enables fetching market info for channel content
+	MarketInfo bool `mapstructure:"market_info"`
+}
+
+// setting describes one configuration key with its default, environment variable and flag.
+// Reloadable settings are applied when the config file changes, the others need a restart;
+// secret settings are never logged
+type setting struct {
+	key        string
+	def        interface{}
+	env        string
+	flag       string
+	usage      string
+	reloadable bool
+	secret     bool
+}
+
+var settings = []setting{
+	{"database.url", "root:gggggggg@tcp(localhost:3306)/twitter_monitor", "DATABASE_URL", "database-url", "database URL: memory://, sqlite://<path>, postgres://... or a MySQL DSN", false, true},
+	{"database.read_timeout", 5 * time.Second, "DATABASE_READ_TIMEOUT", "database-read-timeout", "timeout of each database query, 0 for none", true, false},
+	{"database.write_timeout", 10 * time.Second, "DATABASE_WRITE_TIMEOUT", "database-write-timeout", "timeout of each database change, 0 for none", true, false},
+	{"server.port", 8080, "SERVER_PORT", "port", "HTTP server port", false, false},
+	{"server.environment", "development", "ENVIRONMENT", "environment", "deployment environment name", false, false},
+	{"server.shutdown_timeout", 30 * time.Second, "SHUTDOWN_TIMEOUT", "shutdown-timeout", "time allowed to drain requests and stop background jobs on shutdown", false, false},
+	{"server.admin_token", "", "ADMIN_TOKEN", "admin-token", "bearer token of the /admin endpoints, which are disabled without one", true, true},
+	{"server.ingest_token", "", "INGEST_TOKEN", "ingest-token", "bearer token of the twitter ingest and delete endpoints, which are disabled without one", true, true},
+	{"twitter.base_url", "http://43.160.199.161:5188", "TWITTER_BASE_URL", "twitter-base-url", "base URL of the tw_user_info service", true, false},
+	{"twitter.token", "test0623", "TWITTER_TOKEN", "twitter-token", "token of the tw_user_info service", true, true},
+	{"twitter.timeout", 10 * time.Second, "TWITTER_TIMEOUT", "twitter-timeout", "timeout of tw_user_info requests", true, false},
+	{"market.base_url", "https://api.litrocket.io", "MARKET_BASE_URL", "market-base-url", "base URL of the market info API", true, false},
+	{"market.timeout", 10 * time.Second, "MARKET_TIMEOUT", "market-timeout", "timeout of market info requests", true, false},
+	{"channels.watchlist_limit", 100, "WATCHLIST_LIMIT", "watchlist-limit", "maximum number of accounts in a channel watchlist", true, false},
+	{"channels.page_size", 50, "PAGE_SIZE", "page-size", "default page size of list endpoints", true, false},
+	{"channels.recent_followers_limit", 50, "RECENT_FOLLOWERS_LIMIT", "recent-followers-limit", "number of recent followers kept per channel", false, false},
+	{"channels.restore_days", 7, "CHANNEL_RESTORE_DAYS", "channel-restore-days", "days a deleted channel can be restored before it is purged", true, false},
+	{"jobs.reconcile_fix", false, "RECONCILE_FIX", "reconcile-fix", "let the follower reconciler repair drifted channels", false, false},
+	{"log.level", "info", "LOG_LEVEL", "log-level", "lowest level logged: debug, info, warn or error", true, false},
+	{"log.format", utils.LogFormatText, "LOG_FORMAT", "log-format", "log output format: text or json", false, false},
+	{"features.market_info", true, "FEATURE_MARKET_INFO", "feature-market-info", "fetch market info for channel content", true, false},
+	{"health.timeout", 2 * time.Second, "HEALTH_TIMEOUT", "health-timeout", "timeout of each dependency check of /readyz", true, false},
+	{"tracing.exporter", TracingExporterNone, "TRACING_EXPORTER", "tracing-exporter", "trace exporter: none, stdout or otlp", false, false},
+	{"tracing.endpoint", "http://localhost:4318", "OTEL_EXPORTER_OTLP_ENDPOINT", "tracing-endpoint", "URL of the OTLP/HTTP collector traces are sent to", false, false},
+}
+
+// Load builds the configuration from defaults, the YAML or TOML file named by --config or
+// CONFIG_FILE, environment variables and the flags at the start of args, and validates it.
+// It returns the arguments following the flags, which start with the subcommand if there is one
+func Load(args []string) (*Config, []string, error) {
+	v, rest, _, err := layer(args)
+	if err != nil {
+		return nil, nil, err
+	}
+	cfg, err := decode(v)
+	if err != nil {
+		return nil, nil, err
+	}
+	return cfg, rest, nil
 }
 
-func LoadConfig() *Config {
-	config := &Config{
-		DatabaseURL: getEnv("DATABASE_URL", "root:gggggggg@tcp(localhost:3306)/twitter_monitor"),
-		ServerPort:  getEnvAsInt("SERVER_PORT", 8080),
-		Environment: getEnv("ENVIRONMENT", "development"),
+// layer registers every setting with its default, environment variable and flag on a new
+// viper instance, parses the flags of args and reads the config file. It returns the instance,
+// the arguments following the flags and the config file path, which is empty without a file
+func layer(args []string) (*viper.Viper, []string, string, error) {
+	v := viper.New()
+	flags := pflag.NewFlagSet("TwitterMonitor", pflag.ContinueOnError)
+	flags.SetInterspersed(false)
+	configFile := flags.String("config", "", "YAML or TOML config file")
+
+	for _, s := range settings {
+		v.SetDefault(s.key, s.def)
+		if err := v.BindEnv(s.key, s.env); err != nil {
+			return nil, nil, "", fmt.Errorf("failed to bind %s: %v", s.env, err)
+		}
+		switch def := s.def.(type) {
+		case string:
+			flags.String(s.flag, def, s.usage)
+		case int:
+			flags.Int(s.flag, def, s.usage)
+		case bool:
+			flags.Bool(s.flag, def, s.usage)
+		case time.Duration:
+			flags.Duration(s.flag, def, s.usage)
+		}
+		if err := v.BindPFlag(s.key, flags.Lookup(s.flag)); err != nil {
+			return nil, nil, "", fmt.Errorf("failed to bind --%s: %v", s.flag, err)
+		}
 	}
 
-	return config
+	if err := flags.Parse(args); err != nil {
+		return nil, nil, "", err
+	}
+
+	path := *configFile
+	if path == "" {
+		path = os.Getenv("CONFIG_FILE")
+	}
+	if path != "" {
+		v.SetConfigFile(path)
+		if err := v.ReadInConfig(); err != nil {
+			return nil, nil, "", fmt.Errorf("failed to read config file %s: %v", path, err)
+		}
+	}
+	return v, flags.Args(), path, nil
 }
 
-func getEnv(key, defaultValue string) string {
-	value := os.Getenv(key)
-	if value == "" {
-		return defaultValue
+// decode unmarshals and validates the settings of v
+func decode(v *viper.Viper) (*Config, error) {
+	var cfg Config
+	if err := v.Unmarshal(&cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse configuration: %v", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
 	}
-	return value
+	return &cfg, nil
 }
 
-func getEnvAsInt(key string, defaultValue int) int {
-	valueStr := os.Getenv(key)
-	if valueStr == "" {
-		return defaultValue
+// Validate checks every setting and reports all invalid ones at once
+func (c *Config) Validate() error {
+	var problems []string
+	if c.Database.URL == "" {
+		problems = append(problems, "database.url is required")
+	}
+	if c.Database.ReadTimeout < 0 {
+		problems = append(problems, fmt.Sprintf("database.read_timeout must not be negative, got %s", c.Database.ReadTimeout))
+	}
+	if c.Database.WriteTimeout < 0 {
+		problems = append(problems, fmt.Sprintf("database.write_timeout must not be negative, got %s", c.Database.WriteTimeout))
+	}
+	if c.Server.Port < 1 || c.Server.Port > 65535 {
+		problems = append(problems, fmt.Sprintf("server.port must be between 1 and 65535, got %d", c.Server.Port))
 	}
+	if c.Server.ShutdownTimeout <= 0 {
+		problems = append(problems, fmt.Sprintf("server.shutdown_timeout must be positive, got %s", c.Server.ShutdownTimeout))
+	}
+	if err := validateBaseURL(c.Twitter.BaseURL); err != nil {
+		problems = append(problems, "twitter.base_url "+err.Error())
+	}
+	if c.Twitter.Token == "" {
+		problems = append(problems, "twitter.token is required")
+	}
+	if c.Twitter.Timeout <= 0 {
+		problems = append(problems, fmt.Sprintf("twitter.timeout must be positive, got %s", c.Twitter.Timeout))
+	}
+	if err := validateBaseURL(c.Market.BaseURL); err != nil {
+		problems = append(problems, "market.base_url "+err.Error())
+	}
+	if c.Market.Timeout <= 0 {
+		problems = append(problems, fmt.Sprintf("market.timeout must be positive, got %s", c.Market.Timeout))
+	}
+	if c.Channels.WatchlistLimit < 1 {
+		problems = append(problems, fmt.Sprintf("channels.watchlist_limit must be positive, got %d", c.Channels.WatchlistLimit))
+	}
+	if c.Channels.PageSize < 1 {
+		problems = append(problems, fmt.Sprintf("channels.page_size must be positive, got %d", c.Channels.PageSize))
+	}
+	if c.Channels.RecentFollowersLimit < 1 {
+		problems = append(problems, fmt.Sprintf("channels.recent_followers_limit must be positive, got %d", c.Channels.RecentFollowersLimit))
+	}
+	if c.Channels.RestoreDays < 0 {
+		problems = append(problems, fmt.Sprintf("channels.restore_days must not be negative, got %d", c.Channels.RestoreDays))
+	}
+	if c.Health.Timeout <= 0 {
+		problems = append(problems, fmt.Sprintf("health.timeout must be positive, got %s", c.Health.Timeout))
+	}
+	if _, err := utils.ParseLogLevel(c.Log.Level); err != nil {
+		problems = append(problems, "log.level: "+err.Error())
+	}
+	if c.Log.Format != utils.LogFormatText && c.Log.Format != utils.LogFormatJSON {
+		problems = append(problems, fmt.Sprintf("log.format must be %s or %s, got %q", utils.LogFormatText, utils.LogFormatJSON, c.Log.Format))
+	}
+	switch c.Tracing.Exporter {
+	case TracingExporterNone, TracingExporterStdout:
+	case TracingExporterOTLP:
+		if err := validateBaseURL(c.Tracing.Endpoint); err != nil {
+			problems = append(problems, "tracing.endpoint "+err.Error())
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("tracing.exporter must be %s, %s or %s, got %q",
+			TracingExporterNone, TracingExporterStdout, TracingExporterOTLP, c.Tracing.Exporter))
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid configuration: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
 
-	value, err := strconv.Atoi(valueStr)
+// validateBaseURL checks that a base URL is an absolute http or https URL
+func validateBaseURL(raw string) error {
+	u, err := url.Parse(raw)
 	if err != nil {
-		return defaultValue
+		return fmt.Errorf("is not a valid URL: %v", err)
 	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("must be an absolute http or https URL, got " + raw)
+	}
+	return nil
+}
+
+// redactedValue replaces secrets when the configuration is logged
+const redactedValue = "xxxxx"
 
-	return value
+// Redacted returns a copy of the configuration with the database password, the admin and
+// ingest tokens and the tw_user_info token hidden
+func (c *Config) Redacted() *Config {
+	redacted := *c
+	redacted.Database.URL = redactDatabaseURL(c.Database.URL)
+	if redacted.Server.AdminToken != "" {
+		redacted.Server.AdminToken = redactedValue
+	}
+	if redacted.Server.IngestToken != "" {
+		redacted.Server.IngestToken = redactedValue
+	}
+	if redacted.Twitter.Token != "" {
+		redacted.Twitter.Token = redactedValue
+	}
+	return &redacted
+}
+
+// String formats the configuration for logging, with secrets redacted
+func (c *Config) String() string {
+	return fmt.Sprintf("%+v", *c.Redacted())
+}
+
+// LogValue logs the configuration as its redacted settings
+func (c *Config) LogValue() slog.Value {
+	return slog.AnyValue(c.Values())
+}
+
+// Values returns every setting keyed by its name, with secrets redacted and durations
+// formatted like "10s"
+func (c *Config) Values() map[string]interface{} {
+	return c.Redacted().values()
+}
+
+// values returns every setting keyed by its name, including secrets
+func (c *Config) values() map[string]interface{} {
+	values := make(map[string]interface{})
+	flattenValues(reflect.ValueOf(*c), "", values)
+	return values
+}
+
+func flattenValues(v reflect.Value, prefix string, values map[string]interface{}) {
+	for i := 0; i < v.NumField(); i++ {
+		key := prefix + v.Type().Field(i).Tag.Get("mapstructure")
+		switch field := v.Field(i).Interface().(type) {
+		case time.Duration:
+			values[key] = field.String()
+		default:
+			if v.Field(i).Kind() == reflect.Struct {
+				flattenValues(v.Field(i), key+".", values)
+			} else {
+				values[key] = field
+			}
+		}
+	}
+}
+
+// redactDatabaseURL hides the password of a database URL or MySQL DSN
+func redactDatabaseURL(raw string) string {
+	if dsn, ok := strings.CutPrefix(raw, "mysql://"); ok {
+		return "mysql://" + redactMySQLDSN(dsn)
+	}
+	if scheme, _, ok := strings.Cut(raw, "://"); ok {
+		u, err := url.Parse(raw)
+		if err != nil {
+			return scheme + "://" + redactedValue
+		}
+		if _, ok := u.User.Password(); !ok {
+			return raw
+		}
+		return u.Redacted()
+	}
+	return redactMySQLDSN(raw)
+}
+
+// redactMySQLDSN hides the password of a user:password@protocol(address)/dbname DSN. Like the
+// driver, it takes the last @ before the last / as the end of the credentials
+func redactMySQLDSN(dsn string) string {
+	slash := strings.LastIndex(dsn, "/")
+	if slash < 0 {
+		return dsn
+	}
+	at := strings.LastIndex(dsn[:slash], "@")
+	if at < 0 {
+		return dsn
+	}
+	colon := strings.Index(dsn[:at], ":")
+	if colon < 0 {
+		return dsn
+	}
+	return dsn[:colon+1] + redactedValue + dsn[at:]
 }
@@ -0,0 +1,35 @@
+package config
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// TestExampleFiles loads every shipped example config on top of the defaults; they must be
+// usable as they are
+func TestExampleFiles(t *testing.T) {
+	// Keep the environment of the machine running the tests out of the configuration
+	t.Setenv("CONFIG_FILE", "")
+	for _, s := range settings {
+		t.Setenv(s.env, "")
+	}
+
+	files, err := filepath.Glob("config.example.*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Fatal("no example config files found")
+	}
+	for _, file := range files {
+		t.Run(file, func(t *testing.T) {
+			cfg, _, err := Load([]string{"--config", file})
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+			if err := cfg.Validate(); err != nil {
+				t.Fatalf("Validate: %v", err)
+			}
+		})
+	}
+}
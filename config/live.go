@@ -0,0 +1,184 @@
+package config
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/viper"
+)
+
+// reloadDelay lets a burst of file events, like an editor truncating and then writing the
+// file, settle before the configuration is reloaded
+const reloadDelay = 200 * time.Millisecond
+
+// Live holds the configuration of a running server. When the config file changes, the
+// reloadable settings are applied and the others keep their startup values until a restart
+type Live struct {
+	args    []string
+	path    string
+	startup *viper.Viper
+	current atomic.Pointer[generation]
+
+	mu        sync.Mutex
+	listeners []func(*Config)
+}
+
+// generation is one loaded configuration
+type generation struct {
+	v        *viper.Viper
+	cfg      *Config
+	loadedAt int64
+	// pending lists the restart-only settings whose new value is not applied yet
+	pending []string
+}
+
+// LiveSnapshot is the effective configuration shown by /admin/config
+type LiveSnapshot struct {
+	Settings        map[string]interface{} `json:"settings"`
+	Reloadable      []string               `json:"reloadable"`
+	RestartRequired []string               `json:"restartRequired"`
+	File            string                 `json:"file,omitempty"`
+	LoadedAt        int64                  `json:"loadedAt"`
+}
+
+// LoadLive loads the configuration like Load and keeps what is needed to reload it
+func LoadLive(args []string) (*Live, []string, error) {
+	v, rest, path, err := layer(args)
+	if err != nil {
+		return nil, nil, err
+	}
+	cfg, err := decode(v)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	l := &Live{args: args, path: path, startup: v}
+	l.current.Store(&generation{v: v, cfg: cfg, loadedAt: time.Now().UnixMilli()})
+	return l, rest, nil
+}
+
+// Get returns the current configuration, which must not be modified
+func (l *Live) Get() *Config {
+	return l.current.Load().cfg
+}
+
+// OnReload registers fn to be called with the new configuration after every reload
+func (l *Live) OnReload(fn func(*Config)) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.listeners = append(l.listeners, fn)
+}
+
+// Watch reloads the configuration whenever the config file changes. Without a config file
+// there is nothing to watch
+func (l *Live) Watch() {
+	if l.path == "" {
+		return
+	}
+	reload := time.AfterFunc(reloadDelay, l.Reload)
+	reload.Stop()
+	watcher := viper.New()
+	watcher.SetConfigFile(l.path)
+	watcher.OnConfigChange(func(fsnotify.Event) {
+		reload.Reset(reloadDelay)
+	})
+	watcher.WatchConfig()
+	slog.Info("Watching config file", "path", l.path)
+}
+
+// Reload rebuilds the configuration from the file, environment and flags. An invalid
+// configuration is rejected and the current one kept
+func (l *Live) Reload() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	v, _, _, err := layer(l.args)
+	if err != nil {
+		slog.Error("Rejected configuration reload, keeping the current configuration", "error", err)
+		return
+	}
+
+	// Settings that need a restart keep their startup values
+	var pending []string
+	for _, s := range settings {
+		if s.reloadable {
+			continue
+		}
+		if fmt.Sprint(v.Get(s.key)) != fmt.Sprint(l.startup.Get(s.key)) {
+			pending = append(pending, s.key)
+		}
+		v.Set(s.key, l.startup.Get(s.key))
+	}
+
+	cfg, err := decode(v)
+	if err != nil {
+		slog.Error("Rejected configuration reload, keeping the current configuration", "error", err)
+		return
+	}
+
+	previous := l.current.Load()
+	changes := changedSettings(previous.cfg, cfg)
+	if len(changes) == 0 && strings.Join(pending, ",") == strings.Join(previous.pending, ",") {
+		return
+	}
+	for _, key := range pending {
+		slog.Warn("Config setting changed but is only applied on restart", "setting", key)
+	}
+	l.current.Store(&generation{v: v, cfg: cfg, loadedAt: time.Now().UnixMilli(), pending: pending})
+	if len(changes) > 0 {
+		slog.Info("Configuration reloaded", "changes", strings.Join(changes, ", "))
+	}
+
+	for _, fn := range l.listeners {
+		fn(cfg)
+	}
+}
+
+// Snapshot returns the effective configuration with secrets redacted
+func (l *Live) Snapshot() LiveSnapshot {
+	current := l.current.Load()
+	snapshot := LiveSnapshot{
+		Settings:        current.cfg.Values(),
+		Reloadable:      []string{},
+		RestartRequired: []string{},
+		File:            l.path,
+		LoadedAt:        current.loadedAt,
+	}
+	for _, s := range settings {
+		if s.reloadable {
+			snapshot.Reloadable = append(snapshot.Reloadable, s.key)
+		}
+	}
+	snapshot.RestartRequired = append(snapshot.RestartRequired, current.pending...)
+	return snapshot
+}
+
+// changedSettings describes the settings that differ between two configurations, without
+// the values of secrets
+func changedSettings(from, to *Config) []string {
+	before, after := from.values(), to.values()
+	secret := make(map[string]bool)
+	for _, s := range settings {
+		secret[s.key] = s.secret
+	}
+
+	var changes []string
+	for key, value := range after {
+		if fmt.Sprint(value) == fmt.Sprint(before[key]) {
+			continue
+		}
+		if secret[key] {
+			changes = append(changes, key+" changed")
+		} else {
+			changes = append(changes, fmt.Sprintf("%s %v -> %v", key, before[key], value))
+		}
+	}
+	sort.Strings(changes)
+	return changes
+}
@@ -0,0 +1,106 @@
+package database
+
+import (
+	"TwitterMonitor/internal/models"
+	"context"
+	"fmt"
+	"strings"
+)
+
+// RecordTwitterHandle records that a Twitter account was seen with a username
+func (db *Database) RecordTwitterHandle(ctx context.Context, twitterId, userName string, seenAt int64) error {
+	query := `INSERT INTO twitter_handles (twitterId, userName, firstSeenAt, lastSeenAt)
+	          VALUES (?, ?, ?, ?) ` +
+		db.dialect.upsert([]string{"twitterId", "userName"}, []string{"lastSeenAt"})
+	if _, err := db.db.ExecContext(ctx, query, twitterId, userName, seenAt, seenAt); err != nil {
+		return fmt.Errorf("failed to record Twitter handle: %v", err)
+	}
+	return nil
+}
+
+// GetTwitterHandles gets the usernames a Twitter account has been seen with, most recent first
+func (db *Database) GetTwitterHandles(ctx context.Context, twitterId string) ([]*models.TwitterHandle, error) {
+	query := `SELECT twitterId, userName, firstSeenAt, lastSeenAt FROM twitter_handles WHERE twitterId = ? ORDER BY lastSeenAt DESC`
+	rows, err := db.db.QueryContext(ctx, query, twitterId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query Twitter handles: %v", err)
+	}
+	defer rows.Close()
+
+	var handles []*models.TwitterHandle
+	for rows.Next() {
+		var handle models.TwitterHandle
+		if err := rows.Scan(&handle.TwitterId, &handle.UserName, &handle.FirstSeenAt, &handle.LastSeenAt); err != nil {
+			return nil, fmt.Errorf("failed to scan Twitter handle: %v", err)
+		}
+		handles = append(handles, &handle)
+	}
+
+	return handles, rows.Err()
+}
+
+// UpsertTwitterAccount inserts or refreshes the cached metadata of a Twitter account
+func (db *Database) UpsertTwitterAccount(ctx context.Context, account *models.TwitterAccount) error {
+	query := `INSERT INTO twitter_accounts (twitterId, userName, displayName, avatar, verified, followersCount, followingCount, refreshedAt)
+	          VALUES (?, ?, ?, ?, ?, ?, ?, ?) ` +
+		db.dialect.upsert([]string{"twitterId"}, []string{
+			"userName", "displayName", "avatar", "verified", "followersCount", "followingCount", "refreshedAt",
+		})
+	_, err := db.db.ExecContext(ctx, query,
+		account.TwitterId,
+		account.UserName,
+		account.DisplayName,
+		account.Avatar,
+		account.Verified,
+		account.FollowersCount,
+		account.FollowingCount,
+		account.RefreshedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to upsert Twitter account: %v", err)
+	}
+	return nil
+}
+
+// GetTwitterAccounts gets the cached metadata of Twitter accounts, keyed by Twitter ID
+func (db *Database) GetTwitterAccounts(ctx context.Context, twitterIds []string) (map[string]*models.TwitterAccount, error) {
+	accounts := make(map[string]*models.TwitterAccount)
+	if len(twitterIds) == 0 {
+		return accounts, nil
+	}
+
+	placeholders := make([]string, len(twitterIds))
+	args := make([]interface{}, len(twitterIds))
+	for i, id := range twitterIds {
+		placeholders[i] = "?"
+		args[i] = id
+	}
+
+	query := `SELECT twitterId, userName, COALESCE(displayName, ''), COALESCE(avatar, ''), verified, followersCount, followingCount, refreshedAt
+	          FROM twitter_accounts WHERE twitterId IN (` + strings.Join(placeholders, ",") + ")"
+	rows, err := db.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query Twitter accounts: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var account models.TwitterAccount
+		err := rows.Scan(
+			&account.TwitterId,
+			&account.UserName,
+			&account.DisplayName,
+			&account.Avatar,
+			&account.Verified,
+			&account.FollowersCount,
+			&account.FollowingCount,
+			&account.RefreshedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan Twitter account: %v", err)
+		}
+		accounts[account.TwitterId] = &account
+	}
+
+	return accounts, rows.Err()
+}
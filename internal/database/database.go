@@ -2,7 +2,7 @@ package database
 
 import (
 	"TwitterMonitor/internal/models"
-	"TwitterMonitor/internal/utils"
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
@@ -12,9 +12,11 @@ import (
 	_ "github.com/go-sql-driver/mysql"
 )
 
-// Database represents the MySQL connection
+// Database represents the SQL database connection, MySQL unless created by NewSQLiteDatabase
 type Database struct {
-	db *sql.DB
+	db              *sql.DB
+	dialect         dialect
+	recentFollowers int
 }
 
 // NewDatabase creates a new MySQL connection
@@ -29,28 +31,44 @@ func NewDatabase(uri string) (*Database, error) {
 		return nil, fmt.Errorf("failed to ping MySQL: %v", err)
 	}
 
-	return &Database{db: db}, nil
+	return &Database{db: db, dialect: mysqlDialect, recentFollowers: DefaultRecentFollowersLimit}, nil
 }
 
-// InsertOrUpdateChannel inserts a channel into the MySQL database or updates it if it already exists
-func (db *Database) InsertOrUpdateChannel(channel *models.Channel) error {
+// Dialect names the SQL dialect of the database, mysql or sqlite
+func (db *Database) Dialect() string {
+	return db.dialect.name
+}
+
+// Migrator returns the schema migrator of the database
+func (db *Database) Migrator() (*Migrator, error) {
+	return NewMigrator(db.db, db.dialect)
+}
+
+// Ping checks that the database is reachable
+func (db *Database) Ping(ctx context.Context) error {
+	return db.db.PingContext(ctx)
+}
+
+// PoolStats returns the statistics of the connection pool
+func (db *Database) PoolStats() sql.DBStats {
+	return db.db.Stats()
+}
+
+// Close closes the connection pool, waiting for running queries to finish
+func (db *Database) Close() error {
+	return db.db.Close()
+}
+
+// InsertOrUpdateChannel inserts a channel into the MySQL database or updates it if it already exists,
+// recording a new version by authorID if the versioned configuration changed
+func (db *Database) InsertOrUpdateChannel(ctx context.Context, channel *models.Channel, authorID int) error {
 	now := time.Now().UnixMilli()
 	query := `INSERT INTO channels (id, ownerId, isVerified, name, description, avatar, chatLink, isPublic, isHot, hotExpireAt, createdAt, updatedAt, watchlist, eventlist, followerCount, recentFollowers) 
-	          VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
-	          ON DUPLICATE KEY UPDATE
-	          isVerified = VALUES(isVerified),
-	          name = VALUES(name),
-	          description = VALUES(description),
-	          avatar = VALUES(avatar),
-	          chatLink = VALUES(chatLink),
-	          isPublic = VALUES(isPublic),
-	          isHot = VALUES(isHot),
-	          hotExpireAt = VALUES(hotExpireAt),
-	          updatedAt = VALUES(updatedAt),
-	          watchlist = VALUES(watchlist),
-	          eventlist = VALUES(eventlist),
-	          followerCount = VALUES(followerCount),
-	          recentFollowers = VALUES(recentFollowers)`
+	          VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) ` +
+		db.dialect.upsert([]string{"id"}, []string{
+			"isVerified", "name", "description", "avatar", "chatLink", "isPublic", "isHot", "hotExpireAt",
+			"updatedAt", "watchlist", "eventlist",
+		})
 
 	// 对 Watchlist 进行 JSON 编码
 	watchlistJSON, err := json.Marshal(channel.Watchlist)
@@ -73,7 +91,13 @@ func (db *Database) InsertOrUpdateChannel(channel *models.Channel) error {
 	}
 	eventlistStr := string(eventlistJSON)
 
-	_, err = db.db.Exec(query,
+	tx, err := db.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, query,
 		channel.ID,
 		channel.OwnerID,
 		channel.IsVerified,
@@ -94,73 +118,114 @@ func (db *Database) InsertOrUpdateChannel(channel *models.Channel) error {
 	if err != nil {
 		return fmt.Errorf("failed to insert or update channel: %v", err)
 	}
+
+	if err := db.replaceChannelWatches(ctx, tx, channel.ID, channel.Watchlist); err != nil {
+		return err
+	}
+
+	if err := db.recordChannelVersion(ctx, tx, models.NewChannelVersion(channel, authorID, now)); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %v", err)
+	}
 	return nil
 }
 
-// GetChannelsByOwnerID retrieves channels by OwnerID
-func (db *Database) GetChannelsByOwnerID(ownerID int) ([]*models.Channel, error) {
-	query := `SELECT * FROM channels WHERE ownerId = ?`
-	rows, err := db.db.Query(query, ownerID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to query channels: %v", err)
-	}
-	defer rows.Close()
+// channelColumns is the projection of the channels table read by scanChannel
+const channelColumns = "id, ownerId, isVerified, name, description, avatar, chatLink, isPublic, isHot, hotExpireAt, " +
+	"createdAt, updatedAt, watchlist, eventlist, followerCount, recentFollowers, deletedAt"
 
-	var channels []*models.Channel
-	for rows.Next() {
-		channel := &models.Channel{}
-		var watchlistStr, eventlistStr, recentFollowersStr string
-		err := rows.Scan(
-			&channel.ID,
-			&channel.OwnerID,
-			&channel.IsVerified,
-			&channel.Name,
-			&channel.Description,
-			&channel.Avatar,
-			&channel.ChatLink,
-			&channel.IsPublic,
-			&channel.IsHot,
-			&channel.HotExpireAt,
-			&channel.CreatedAt,
-			&channel.UpdatedAt,
-			&watchlistStr,
-			&eventlistStr,
-			&channel.FollowerCount,
-			&recentFollowersStr,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan channel: %v", err)
-		}
+// liveChannel is the condition selecting channels that have not been deleted
+const liveChannel = "deletedAt IS NULL"
 
-		// Unmarshal Watchlist
-		if err := json.Unmarshal([]byte(watchlistStr), &channel.Watchlist); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal watchlist: %v", err)
-		}
+// rowScanner is implemented by *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
 
-		// Unmarshal Eventlist
-		if err := json.Unmarshal([]byte(eventlistStr), &channel.Eventlist); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal eventlist: %v", err)
-		}
+// scanChannel scans a row selected with channelColumns. Nullable columns read as their zero
+// value and NULL JSON columns as empty lists
+func scanChannel(row rowScanner) (*models.Channel, error) {
+	var channel models.Channel
+	var isVerified, isPublic, isHot sql.NullBool
+	var description, avatar, chatLink, hotExpireAt sql.NullString
+	var createdAt, updatedAt, followerCount, deletedAt sql.NullInt64
+	var watchlist, eventlist, recentFollowers []byte
+	err := row.Scan(
+		&channel.ID,
+		&channel.OwnerID,
+		&isVerified,
+		&channel.Name,
+		&description,
+		&avatar,
+		&chatLink,
+		&isPublic,
+		&isHot,
+		&hotExpireAt,
+		&createdAt,
+		&updatedAt,
+		&watchlist,
+		&eventlist,
+		&followerCount,
+		&recentFollowers,
+		&deletedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
 
-		// Unmarshal RecentFollowers
-		if err := json.Unmarshal([]byte(recentFollowersStr), &channel.RecentFollowers); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal recentFollowers: %v", err)
-		}
+	channel.IsVerified = isVerified.Bool
+	channel.Description = description.String
+	channel.Avatar = avatar.String
+	channel.ChatLink = chatLink.String
+	channel.IsPublic = isPublic.Bool
+	channel.IsHot = isHot.Bool
+	channel.HotExpireAt = hotExpireAt.String
+	channel.CreatedAt = createdAt.Int64
+	channel.UpdatedAt = updatedAt.Int64
+	channel.FollowerCount = int(followerCount.Int64)
+	channel.DeletedAt = deletedAt.Int64
 
-		channels = append(channels, channel)
+	if err := unmarshalJSONColumn(watchlist, &channel.Watchlist); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal watchlist: %v", err)
+	}
+	if err := unmarshalJSONColumn(eventlist, &channel.Eventlist); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal eventlist: %v", err)
+	}
+	if err := unmarshalJSONColumn(recentFollowers, &channel.RecentFollowers); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal recentFollowers: %v", err)
 	}
+	emptyChannelLists(&channel)
+	return &channel, nil
+}
 
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating rows: %v", err)
+// unmarshalJSONColumn decodes a JSON column, leaving v unchanged for NULL or empty values
+func unmarshalJSONColumn(data []byte, v interface{}) error {
+	if len(data) == 0 {
+		return nil
 	}
+	return json.Unmarshal(data, v)
+}
 
-	return channels, nil
+// emptyChannelLists replaces missing watchlist, eventlist and recentFollowers values with
+// empty lists, so they are returned as [] rather than null
+func emptyChannelLists(channel *models.Channel) {
+	if channel.Watchlist == nil {
+		channel.Watchlist = []models.Watchlist{}
+	}
+	if channel.Eventlist == nil {
+		channel.Eventlist = []models.EventList{}
+	}
+	if channel.RecentFollowers == nil {
+		channel.RecentFollowers = []int{}
+	}
 }
 
-// GetChannelsByID retrieves channels by OwnerID
-func (db *Database) GetChannelsByID(id string) ([]*models.Channel, error) {
-	query := `SELECT * FROM channels WHERE id = ?`
-	rows, err := db.db.Query(query, id)
+// queryChannels runs a query selecting channelColumns and scans every row
+func (db *Database) queryChannels(ctx context.Context, query string, args ...interface{}) ([]*models.Channel, error) {
+	rows, err := db.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query channels: %v", err)
 	}
@@ -168,45 +233,10 @@ func (db *Database) GetChannelsByID(id string) ([]*models.Channel, error) {
 
 	var channels []*models.Channel
 	for rows.Next() {
-		channel := &models.Channel{}
-		var watchlistStr, eventlistStr, recentFollowersStr string
-		err := rows.Scan(
-			&channel.ID,
-			&channel.OwnerID,
-			&channel.IsVerified,
-			&channel.Name,
-			&channel.Description,
-			&channel.Avatar,
-			&channel.ChatLink,
-			&channel.IsPublic,
-			&channel.IsHot,
-			&channel.HotExpireAt,
-			&channel.CreatedAt,
-			&channel.UpdatedAt,
-			&watchlistStr,
-			&eventlistStr,
-			&channel.FollowerCount,
-			&recentFollowersStr,
-		)
+		channel, err := scanChannel(rows)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan channel: %v", err)
 		}
-
-		// Unmarshal Watchlist
-		if err := json.Unmarshal([]byte(watchlistStr), &channel.Watchlist); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal watchlist: %v", err)
-		}
-
-		// Unmarshal Eventlist
-		if err := json.Unmarshal([]byte(eventlistStr), &channel.Eventlist); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal eventlist: %v", err)
-		}
-
-		// Unmarshal RecentFollowers
-		if err := json.Unmarshal([]byte(recentFollowersStr), &channel.RecentFollowers); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal recentFollowers: %v", err)
-		}
-
 		channels = append(channels, channel)
 	}
 
@@ -217,10 +247,27 @@ func (db *Database) GetChannelsByID(id string) ([]*models.Channel, error) {
 	return channels, nil
 }
 
-// DeleteChannel deletes a channel by its ID
-func (db *Database) DeleteChannel(channelID string) error {
-	query := `DELETE FROM channels WHERE id = ?`
-	result, err := db.db.Exec(query, channelID)
+// GetChannelsByOwnerID retrieves channels by OwnerID
+func (db *Database) GetChannelsByOwnerID(ctx context.Context, ownerID int) ([]*models.Channel, error) {
+	return db.queryChannels(ctx, "SELECT "+channelColumns+" FROM channels WHERE ownerId = ? AND "+liveChannel, ownerID)
+}
+
+// GetChannelsByID retrieves channels by ID
+func (db *Database) GetChannelsByID(ctx context.Context, id string) ([]*models.Channel, error) {
+	return db.queryChannels(ctx, "SELECT "+channelColumns+" FROM channels WHERE id = ? AND "+liveChannel, id)
+}
+
+// DeleteChannel marks a channel deleted and notifies its followers in one transaction,
+// returning sql.ErrNoRows if there is no live channel with that ID
+func (db *Database) DeleteChannel(ctx context.Context, channelID string, deletedAt int64) error {
+	tx, err := db.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	query := "UPDATE channels SET deletedAt = ? WHERE id = ? AND " + liveChannel
+	result, err := tx.ExecContext(ctx, query, deletedAt, channelID)
 	if err != nil {
 		return fmt.Errorf("failed to delete channel: %v", err)
 	}
@@ -231,324 +278,454 @@ func (db *Database) DeleteChannel(channelID string) error {
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("channel not found")
+		return sql.ErrNoRows
+	}
+
+	if err := db.notifyFollowers(ctx, tx, channelID, models.NotificationChannelDeleted, deletedAt); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %v", err)
 	}
 
 	return nil
 }
 
-// UpdateFollowerCount updates the follower count and recent followers of a channel
-func (db *Database) UpdateFollowerCount(channelID string, userID int, increment bool) error {
-	// Start transaction
-	tx, err := db.db.Begin()
+// GetDeletedChannel gets a deleted channel by its ID, returning sql.ErrNoRows if there is no
+// such channel or it was not deleted
+func (db *Database) GetDeletedChannel(ctx context.Context, channelID string) (*models.Channel, error) {
+	row := db.db.QueryRowContext(ctx, "SELECT "+channelColumns+" FROM channels WHERE id = ? AND deletedAt IS NOT NULL", channelID)
+	channel, err := scanChannel(row)
+	if err == sql.ErrNoRows {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan channel: %v", err)
+	}
+	return channel, nil
+}
+
+// RestoreChannel clears the deletion mark of a channel and notifies its followers in one
+// transaction, returning sql.ErrNoRows if the channel is not deleted
+func (db *Database) RestoreChannel(ctx context.Context, channelID string) error {
+	tx, err := db.db.BeginTx(ctx, nil)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %v", err)
 	}
 	defer tx.Rollback()
 
-	// Update follower count
-	operator := "-"
-	if increment {
-		operator = "+"
-	}
-	query := fmt.Sprintf("UPDATE channels SET followerCount = CAST(followerCount AS SIGNED) %s 1 WHERE id = ?", operator)
-	_, err = tx.Exec(query, channelID)
+	result, err := tx.ExecContext(ctx, "UPDATE channels SET deletedAt = NULL WHERE id = ? AND deletedAt IS NOT NULL", channelID)
 	if err != nil {
-		utils.LogError("Error updating follower count: %v", err)
-		return fmt.Errorf("failed to update follower count: %v", err)
+		return fmt.Errorf("failed to restore channel: %v", err)
 	}
-
-	// Get current recentFollowersStr
-	var currentFollowersStr string
-	err = tx.QueryRow("SELECT recentFollowers FROM channels WHERE id = ?", channelID).Scan(&currentFollowersStr)
+	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		utils.LogError("Error updating follower count: %v", err)
-		return fmt.Errorf("failed to get current followers: %v", err)
+		return fmt.Errorf("failed to get rows affected: %v", err)
 	}
-
-	// Parse current followers
-	var currentFollowers []int
-	if currentFollowersStr != "" {
-		if err := json.Unmarshal([]byte(currentFollowersStr), &currentFollowers); err != nil {
-			return fmt.Errorf("failed to parse current followers: %v", err)
-		}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
 	}
 
-	// Add new follower and ensure uniqueness
-	exists := false
-	for _, id := range currentFollowers {
-		if id == userID {
-			exists = true
-			break
-		}
+	if err := db.notifyFollowers(ctx, tx, channelID, models.NotificationChannelRestored, time.Now().UnixMilli()); err != nil {
+		return err
 	}
-	if increment {
-		if !exists {
-			// Add new follower at the beginning
-			currentFollowers = append([]int{userID}, currentFollowers...)
-			// Keep only the most recent 50 followers
-			if len(currentFollowers) > 50 {
-				currentFollowers = currentFollowers[:50]
-			}
-		}
-	} else {
-		if exists {
-			// Remove follower
-			for i, id := range currentFollowers {
-				if id == userID {
-					currentFollowers = append(currentFollowers[:i], currentFollowers[i+1:]...)
-					break
-				}
-			}
-		}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %v", err)
 	}
+	return nil
+}
 
-	// Convert back to JSON
-	newFollowersStr, err := json.Marshal(currentFollowers)
+// PurgeDeletedChannels removes the channels deleted before deletedBefore together with their
+// follows, watchlist entries and versions, returning how many channels were removed
+func (db *Database) PurgeDeletedChannels(ctx context.Context, deletedBefore int64) (int, error) {
+	tx, err := db.db.BeginTx(ctx, nil)
 	if err != nil {
-		return fmt.Errorf("failed to marshal followers: %v", err)
+		return 0, fmt.Errorf("failed to begin transaction: %v", err)
 	}
+	defer tx.Rollback()
 
-	// Update recentFollowersStr
-	_, err = tx.Exec("UPDATE channels SET recentFollowers = ? WHERE id = ?", string(newFollowersStr), channelID)
+	purged := "SELECT id FROM channels WHERE deletedAt IS NOT NULL AND deletedAt < ?"
+	if _, err := tx.ExecContext(ctx, "DELETE FROM follows WHERE channelId IN ("+purged+")", deletedBefore); err != nil {
+		return 0, fmt.Errorf("failed to delete follows: %v", err)
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM channel_watch WHERE channelId IN ("+purged+")", deletedBefore); err != nil {
+		return 0, fmt.Errorf("failed to delete channel watches: %v", err)
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM channel_versions WHERE channelId IN ("+purged+")", deletedBefore); err != nil {
+		return 0, fmt.Errorf("failed to delete channel versions: %v", err)
+	}
+	result, err := tx.ExecContext(ctx, "DELETE FROM channels WHERE deletedAt IS NOT NULL AND deletedAt < ?", deletedBefore)
 	if err != nil {
-		return fmt.Errorf("failed to update recent followers: %v", err)
+		return 0, fmt.Errorf("failed to delete channels: %v", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %v", err)
 	}
 
-	// Commit transaction
 	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("failed to commit transaction: %v", err)
+		return 0, fmt.Errorf("failed to commit transaction: %v", err)
+	}
+	return int(rowsAffected), nil
+}
+
+// lockChannel locks a channel row for the rest of tx, returning sql.ErrNoRows if it does not exist
+// or was deleted
+func (db *Database) lockChannel(ctx context.Context, tx *sql.Tx, channelID string) error {
+	var id string
+	err := tx.QueryRowContext(ctx, "SELECT id FROM channels WHERE id = ? AND "+liveChannel+db.dialect.forUpdate(), channelID).Scan(&id)
+	if err == sql.ErrNoRows {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("failed to lock channel: %v", err)
+	}
+	return nil
+}
+
+// refreshRecentFollowers rewrites the recentFollowers of a channel from its most recent follows within tx
+func (db *Database) refreshRecentFollowers(ctx context.Context, tx *sql.Tx, channelID string) error {
+	rows, err := tx.QueryContext(ctx, "SELECT userId FROM follows WHERE channelId = ? ORDER BY createdAt DESC LIMIT ?", channelID, db.recentFollowers)
+	if err != nil {
+		return fmt.Errorf("failed to query recent followers: %v", err)
+	}
+	followers := []int{}
+	for rows.Next() {
+		var userID int
+		if err := rows.Scan(&userID); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan follower: %v", err)
+		}
+		followers = append(followers, userID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to query recent followers: %v", err)
 	}
 
+	recentFollowersJSON, err := json.Marshal(followers)
+	if err != nil {
+		return fmt.Errorf("failed to marshal recent followers: %v", err)
+	}
+	_, err = tx.ExecContext(ctx, "UPDATE channels SET recentFollowers = ? WHERE id = ?", string(recentFollowersJSON), channelID)
+	if err != nil {
+		return fmt.Errorf("failed to update recent followers: %v", err)
+	}
 	return nil
 }
 
-// FollowChannel creates a follow relationship between a user and a channel
-func (db *Database) FollowChannel(follow *models.Follow) error {
-	now := time.Now().UnixMilli()
+// FollowChannel creates a follow relationship between a user and a channel and updates the
+// channel's follower count in one transaction. It returns false without changing anything
+// when the user already follows the channel
+func (db *Database) FollowChannel(ctx context.Context, follow *models.Follow) (bool, error) {
+	follow.CreatedAt = time.Now().UnixMilli()
 
 	// Start a transaction
-	tx, err := db.db.Begin()
+	tx, err := db.db.BeginTx(ctx, nil)
 	if err != nil {
-		return fmt.Errorf("failed to start transaction: %v", err)
+		return false, fmt.Errorf("failed to start transaction: %v", err)
 	}
 	defer tx.Rollback()
 
-	// Insert follow relationship
-	query := `INSERT INTO follows (id, userId, channelId, createdAt) VALUES (?, ?, ?, ?)`
-	_, err = tx.Exec(query,
+	// Lock the channel first so concurrent follows of the same channel are serialized
+	if err := db.lockChannel(ctx, tx, follow.ChannelID); err != nil {
+		return false, err
+	}
+
+	// Insert follow relationship; the unique key on (userId, channelId) makes a repeat a no-op
+	query := `INSERT INTO follows (id, userId, channelId, createdAt) VALUES (?, ?, ?, ?) ` +
+		db.dialect.insertIgnore([]string{"userId", "channelId"})
+	result, err := tx.ExecContext(ctx, query,
 		follow.ID,
 		follow.UserID,
 		follow.ChannelID,
-		now,
+		follow.CreatedAt,
 	)
 	if err != nil {
-		return fmt.Errorf("failed to follow channel: %v", err)
+		return false, fmt.Errorf("failed to follow channel: %v", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %v", err)
+	}
+	if rowsAffected == 0 {
+		return false, nil
 	}
 
-	// Update follower count
-	if err := db.UpdateFollowerCount(follow.ChannelID, follow.UserID, true); err != nil {
-		utils.LogError("failed to update follower count: %v", err)
-		return err
+	if _, err := tx.ExecContext(ctx, "UPDATE channels SET followerCount = followerCount + 1 WHERE id = ?", follow.ChannelID); err != nil {
+		return false, fmt.Errorf("failed to update follower count: %v", err)
+	}
+	if err := db.refreshRecentFollowers(ctx, tx, follow.ChannelID); err != nil {
+		return false, err
 	}
 
 	// Commit transaction
 	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("failed to commit transaction: %v", err)
+		return false, fmt.Errorf("failed to commit transaction: %v", err)
 	}
 
-	return nil
+	return true, nil
 }
 
-// UnfollowChannel removes a follow relationship between a user and a channel
-func (db *Database) UnfollowChannel(userID int, channelID string) error {
+// UnfollowChannel removes a follow relationship between a user and a channel and updates the
+// channel's follower count in one transaction. It returns false without changing anything
+// when the user does not follow the channel
+func (db *Database) UnfollowChannel(ctx context.Context, userID int, channelID string) (bool, error) {
 	// Start a transaction
-	tx, err := db.db.Begin()
+	tx, err := db.db.BeginTx(ctx, nil)
 	if err != nil {
-		return fmt.Errorf("failed to start transaction: %v", err)
+		return false, fmt.Errorf("failed to start transaction: %v", err)
 	}
 	defer tx.Rollback()
 
+	if err := db.lockChannel(ctx, tx, channelID); err != nil {
+		return false, err
+	}
+
 	// Delete follow relationship
 	query := `DELETE FROM follows WHERE userId = ? AND channelId = ?`
-	result, err := tx.Exec(query, userID, channelID)
+	result, err := tx.ExecContext(ctx, query, userID, channelID)
 	if err != nil {
-		return fmt.Errorf("failed to unfollow channel: %v", err)
+		return false, fmt.Errorf("failed to unfollow channel: %v", err)
 	}
 
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %v", err)
+		return false, fmt.Errorf("failed to get rows affected: %v", err)
 	}
-
 	if rowsAffected == 0 {
-		return fmt.Errorf("follow relationship not found")
+		return false, nil
 	}
 
-	// Update follower count
-	if err := db.UpdateFollowerCount(channelID, userID, false); err != nil {
-		return err
+	query = `UPDATE channels SET followerCount = CASE WHEN followerCount > 0 THEN followerCount - 1 ELSE 0 END WHERE id = ?`
+	if _, err := tx.ExecContext(ctx, query, channelID); err != nil {
+		return false, fmt.Errorf("failed to update follower count: %v", err)
+	}
+	if err := db.refreshRecentFollowers(ctx, tx, channelID); err != nil {
+		return false, err
 	}
 
 	// Commit transaction
 	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("failed to commit transaction: %v", err)
+		return false, fmt.Errorf("failed to commit transaction: %v", err)
 	}
 
-	return nil
+	return true, nil
 }
 
-// IsFollowing checks if a user is following a channel
-func (db *Database) IsFollowing(userID int, channelID string) (bool, error) {
-	query := `SELECT COUNT(*) FROM follows WHERE userId = ? AND channelId = ?`
+// CountFollowers counts the follows of a channel
+func (db *Database) CountFollowers(ctx context.Context, channelID string) (int, error) {
 	var count int
-	err := db.db.QueryRow(query, userID, channelID).Scan(&count)
+	err := db.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM follows WHERE channelId = ?", channelID).Scan(&count)
 	if err != nil {
-		return false, fmt.Errorf("failed to check follow status: %v", err)
+		return 0, fmt.Errorf("failed to count followers: %v", err)
 	}
-	return count > 0, nil
+	return count, nil
 }
 
-// GetTwitterInfoByTypeAndCA gets Twitter info by type and CA address
-func (db *Database) GetTwitterInfoByTypeAndCA(type_ int, caAddresses []string, limit, offset int) ([]models.TwitterInfo, error) {
-	var twitterInfos []models.TwitterInfo
-	var query string
-	var args []interface{}
+// CountChannels counts the channels that have not been deleted
+func (db *Database) CountChannels(ctx context.Context) (int, error) {
+	var count int
+	if err := db.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM channels WHERE "+liveChannel).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count channels: %v", err)
+	}
+	return count, nil
+}
 
-	query = "SELECT id, twitterId, content, chainId, address, createTime, type FROM twitter_info WHERE type = ?"
-	args = append(args, type_)
+// CountFollows counts the follows of channels that have not been deleted
+func (db *Database) CountFollows(ctx context.Context) (int, error) {
+	var count int
+	query := "SELECT COUNT(*) FROM follows WHERE channelId IN (SELECT id FROM channels WHERE " + liveChannel + ")"
+	if err := db.db.QueryRowContext(ctx, query).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count follows: %v", err)
+	}
+	return count, nil
+}
 
-	if len(caAddresses) > 0 {
-		placeholders := make([]string, len(caAddresses))
-		for i := range caAddresses {
-			placeholders[i] = "?"
-			args = append(args, caAddresses[i])
-		}
-		query += " AND address IN (" + strings.Join(placeholders, ",") + ")"
+// RepairFollowerStats recomputes the followerCount and recentFollowers of a channel from its
+// follows in one transaction
+func (db *Database) RepairFollowerStats(ctx context.Context, channelID string) error {
+	tx, err := db.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %v", err)
 	}
+	defer tx.Rollback()
 
-	query += " ORDER BY createTime DESC LIMIT ? OFFSET ?"
-	args = append(args, limit, offset)
+	if err := db.lockChannel(ctx, tx, channelID); err != nil {
+		return err
+	}
 
-	rows, err := db.db.Query(query, args...)
-	if err != nil {
-		return nil, err
+	query := `UPDATE channels SET followerCount = (SELECT COUNT(*) FROM follows WHERE channelId = ?) WHERE id = ?`
+	if _, err := tx.ExecContext(ctx, query, channelID, channelID); err != nil {
+		return fmt.Errorf("failed to update follower count: %v", err)
+	}
+	if err := db.refreshRecentFollowers(ctx, tx, channelID); err != nil {
+		return err
 	}
-	defer rows.Close()
 
-	for rows.Next() {
-		var info models.TwitterInfo
-		err := rows.Scan(&info.ID, &info.TwitterId, &info.Content, &info.ChainId, &info.Address, &info.CreateTime, &info.Type)
-		if err != nil {
-			return nil, err
-		}
-		twitterInfos = append(twitterInfos, info)
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %v", err)
 	}
+	return nil
+}
 
-	return twitterInfos, nil
+// IsFollowing checks if a user is following a channel
+func (db *Database) IsFollowing(ctx context.Context, userID int, channelID string) (bool, error) {
+	query := `SELECT COUNT(*) FROM follows WHERE userId = ? AND channelId = ?`
+	var count int
+	err := db.db.QueryRowContext(ctx, query, userID, channelID).Scan(&count)
+	if err != nil {
+		return false, fmt.Errorf("failed to check follow status: %v", err)
+	}
+	return count > 0, nil
 }
 
-// GetTwitterInfoByType gets Twitter info by type only
-func (db *Database) GetTwitterInfoByType(type_ int, limit, offset int) ([]models.TwitterInfo, error) {
-	var twitterInfos []models.TwitterInfo
+// GetTwitterInfo gets the page of Twitter info selected by a content query
+func (db *Database) GetTwitterInfo(ctx context.Context, query ContentQuery) ([]*models.TwitterInfo, error) {
+	where, args := query.where(sqlContentColumns)
+	sqlQuery := `
+		SELECT id, tweetsId, twitterId, content, COALESCE(chainId, ''), COALESCE(address, ''), COALESCE(cashtags, ''), createTime, type
+		FROM twitter_info
+		WHERE ` + where + `
+		ORDER BY createTime DESC LIMIT ? OFFSET ?`
 
-	query := "SELECT id, twitterId, content, chainId, address, createTime, type FROM twitter_info WHERE type = ? ORDER BY createTime DESC LIMIT ? OFFSET ?"
-	rows, err := db.db.Query(query, type_, limit, offset)
+	rows, err := db.db.QueryContext(ctx, sqlQuery, append(args, query.Limit, query.Offset)...)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed to query Twitter info: %v", err)
 	}
 	defer rows.Close()
 
+	var twitterInfos []*models.TwitterInfo
 	for rows.Next() {
 		var info models.TwitterInfo
-		err := rows.Scan(&info.ID, &info.TwitterId, &info.Content, &info.ChainId, &info.Address, &info.CreateTime, &info.Type)
-		if err != nil {
-			return nil, err
+		if err := rows.Scan(&info.ID, &info.TweetsId, &info.TwitterId, &info.Content, &info.ChainId, &info.Address, &info.Cashtags, &info.CreateTime, &info.Type); err != nil {
+			return nil, fmt.Errorf("failed to scan Twitter info: %v", err)
 		}
-		twitterInfos = append(twitterInfos, info)
+		twitterInfos = append(twitterInfos, &info)
+	}
+
+	if err := db.loadTwitterInfoAddresses(ctx, twitterInfos); err != nil {
+		return nil, err
 	}
 
 	return twitterInfos, nil
 }
 
-// GetTwitterInfoByWatchlist gets Twitter info based on watchlist conditions
-func (db *Database) GetTwitterInfoByWatchlist(conditions []string, contentType int, limit, offset int) ([]*models.TwitterInfo, error) {
-	query := `
-		SELECT id, twitterId, content, COALESCE(chainId, ''), COALESCE(address, ''), createTime, type
-		FROM twitter_info
-		WHERE type = ?
-	`
-
-	if len(conditions) > 0 {
-		query += " AND (" + strings.Join(conditions, " OR ") + ")"
+// InsertTwitterInfo inserts a Twitter info record or updates it if the tweet was already ingested,
+// replacing the contract addresses recorded for it
+func (db *Database) InsertTwitterInfo(ctx context.Context, info *models.TwitterInfo) error {
+	if info.CreateTime == 0 {
+		info.CreateTime = time.Now().UnixMilli()
 	}
 
-	query += " ORDER BY createTime DESC LIMIT ? OFFSET ?"
+	tx, err := db.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %v", err)
+	}
+	defer tx.Rollback()
 
-	rows, err := db.db.Query(query, contentType, limit, offset)
+	query := `INSERT INTO twitter_info (tweetsId, twitterId, content, chainId, address, cashtags, createTime, type)
+	          VALUES (?, ?, ?, ?, ?, ?, ?, ?) ` +
+		db.dialect.upsert([]string{"tweetsId"}, []string{"twitterId", "content", "chainId", "address", "cashtags", "type"})
+	_, err = tx.ExecContext(ctx, query,
+		info.TweetsId,
+		info.TwitterId,
+		info.Content,
+		info.ChainId,
+		info.Address,
+		info.Cashtags,
+		info.CreateTime,
+		info.Type,
+	)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query Twitter info: %v", err)
+		return fmt.Errorf("failed to insert Twitter info: %v", err)
 	}
-	defer rows.Close()
 
-	var twitterInfos []*models.TwitterInfo
-	for rows.Next() {
-		var info models.TwitterInfo
-		if err := rows.Scan(&info.ID, &info.TwitterId, &info.Content, &info.ChainId, &info.Address, &info.CreateTime, &info.Type); err != nil {
-			return nil, fmt.Errorf("failed to scan Twitter info: %v", err)
+	if _, err := tx.ExecContext(ctx, "DELETE FROM twitter_info_address WHERE tweetsId = ?", info.TweetsId); err != nil {
+		return fmt.Errorf("failed to clear Twitter info addresses: %v", err)
+	}
+	for i, addr := range info.Addresses {
+		_, err := tx.ExecContext(ctx, "INSERT INTO twitter_info_address (tweetsId, chainId, address, position) VALUES (?, ?, ?, ?)",
+			info.TweetsId, addr.ChainId, addr.Address, i)
+		if err != nil {
+			return fmt.Errorf("failed to insert Twitter info address: %v", err)
 		}
-		twitterInfos = append(twitterInfos, &info)
 	}
 
-	return twitterInfos, nil
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %v", err)
+	}
+
+	return nil
 }
 
-// GetTwitterInfoByProfileAndFollow gets Twitter info based on profile and follow conditions
-func (db *Database) GetTwitterInfoByProfileAndFollow(twitterIds []string, contentType int, limit, offset int) ([]*models.TwitterInfo, error) {
-	if len(twitterIds) == 0 {
-		return nil, nil
+// MarkTwitterInfoDeleted records that tweets were deleted by their author
+func (db *Database) MarkTwitterInfoDeleted(ctx context.Context, tweetsIds []string, deletedAt int64) error {
+	if len(tweetsIds) == 0 {
+		return nil
 	}
 
-	placeholders := make([]string, len(twitterIds))
-	args := make([]interface{}, len(twitterIds)+3)
-	for i := range twitterIds {
+	placeholders := make([]string, len(tweetsIds))
+	args := make([]interface{}, 0, len(tweetsIds)+1)
+	args = append(args, deletedAt)
+	for i, id := range tweetsIds {
 		placeholders[i] = "?"
-		args[i] = twitterIds[i]
+		args = append(args, id)
 	}
-	args[len(twitterIds)] = contentType
-	args[len(twitterIds)+1] = limit
-	args[len(twitterIds)+2] = offset
 
-	query := fmt.Sprintf(`
-		SELECT id, twitter_id, content, COALESCE(chain_id, ''), COALESCE(address, ''), create_time, type
-		FROM twitter_info
-		WHERE twitter_id IN (%s) AND type = ?
-		ORDER BY create_time DESC
-		LIMIT ? OFFSET ?
-	`, strings.Join(placeholders, ","))
+	query := "UPDATE twitter_info SET deletedAt = ? WHERE deletedAt IS NULL AND tweetsId IN (" + strings.Join(placeholders, ",") + ")"
+	if _, err := db.db.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to mark Twitter info deleted: %v", err)
+	}
+	return nil
+}
+
+// loadTwitterInfoAddresses fills the Addresses of each record from twitter_info_address
+func (db *Database) loadTwitterInfoAddresses(ctx context.Context, twitterInfos []*models.TwitterInfo) error {
+	if len(twitterInfos) == 0 {
+		return nil
+	}
 
-	rows, err := db.db.Query(query, args...)
+	byTweet := make(map[string]*models.TwitterInfo, len(twitterInfos))
+	placeholders := make([]string, 0, len(twitterInfos))
+	args := make([]interface{}, 0, len(twitterInfos))
+	for _, info := range twitterInfos {
+		if _, ok := byTweet[info.TweetsId]; ok {
+			continue
+		}
+		byTweet[info.TweetsId] = info
+		placeholders = append(placeholders, "?")
+		args = append(args, info.TweetsId)
+	}
+
+	query := "SELECT tweetsId, chainId, address FROM twitter_info_address WHERE tweetsId IN (" + strings.Join(placeholders, ",") + ") ORDER BY position"
+	rows, err := db.db.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query Twitter info: %v", err)
+		return fmt.Errorf("failed to query Twitter info addresses: %v", err)
 	}
 	defer rows.Close()
 
-	var twitterInfos []*models.TwitterInfo
 	for rows.Next() {
-		var info models.TwitterInfo
-		if err := rows.Scan(&info.ID, &info.TwitterId, &info.Content, &info.ChainId, &info.Address, &info.CreateTime, &info.Type); err != nil {
-			return nil, fmt.Errorf("failed to scan Twitter info: %v", err)
+		var tweetsId string
+		var addr models.ContractAddress
+		if err := rows.Scan(&tweetsId, &addr.ChainId, &addr.Address); err != nil {
+			return fmt.Errorf("failed to scan Twitter info address: %v", err)
+		}
+		if info, ok := byTweet[tweetsId]; ok {
+			info.Addresses = append(info.Addresses, addr)
 		}
-		twitterInfos = append(twitterInfos, &info)
 	}
 
-	return twitterInfos, nil
+	return rows.Err()
 }
 
-// GetFollowedChannels gets all channels followed by a user
-func (db *Database) GetFollowedChannels(userID int) ([]*models.Follow, error) {
+// GetFollowedChannels gets all channels followed by a user, skipping deleted channels
+func (db *Database) GetFollowedChannels(ctx context.Context, userID int) ([]*models.Follow, error) {
 	var follows []*models.Follow
-	rows, err := db.db.Query("SELECT * FROM follows WHERE userId = ?", userID)
+	query := "SELECT id, userId, channelId, createdAt FROM follows WHERE userId = ? AND channelId IN (SELECT id FROM channels WHERE " + liveChannel + ")"
+	rows, err := db.db.QueryContext(ctx, query, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -566,114 +743,30 @@ func (db *Database) GetFollowedChannels(userID int) ([]*models.Follow, error) {
 	return follows, nil
 }
 
-// GetChannelByID gets a channel by its ID
-func (db *Database) GetChannelByID(channelID string) (*models.Channel, error) {
-	var channel models.Channel
-	row := db.db.QueryRow("SELECT * FROM channels WHERE id = ?", channelID)
-	var watchlistStr, eventlistStr, recentFollowersStr string
-	err := row.Scan(
-		&channel.ID,
-		&channel.OwnerID,
-		&channel.IsVerified,
-		&channel.Name,
-		&channel.Description,
-		&channel.Avatar,
-		&channel.ChatLink,
-		&channel.IsPublic,
-		&channel.IsHot,
-		&channel.HotExpireAt,
-		&channel.CreatedAt,
-		&channel.UpdatedAt,
-		&watchlistStr,
-		&eventlistStr,
-		&channel.FollowerCount,
-		&recentFollowersStr,
-	)
-
-	// Unmarshal Watchlist
-	if err := json.Unmarshal([]byte(watchlistStr), &channel.Watchlist); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal watchlist: %v", err)
-	}
-
-	// Unmarshal Eventlist
-	if err := json.Unmarshal([]byte(eventlistStr), &channel.Eventlist); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal eventlist: %v", err)
-	}
-
-	// Unmarshal RecentFollowers
-	if err := json.Unmarshal([]byte(recentFollowersStr), &channel.RecentFollowers); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal recentFollowers: %v", err)
+// GetChannelByID gets a channel by its ID, returning sql.ErrNoRows if it does not exist
+func (db *Database) GetChannelByID(ctx context.Context, channelID string) (*models.Channel, error) {
+	row := db.db.QueryRowContext(ctx, "SELECT "+channelColumns+" FROM channels WHERE id = ? AND "+liveChannel, channelID)
+	channel, err := scanChannel(row)
+	if err == sql.ErrNoRows {
+		return nil, err
 	}
-
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed to scan channel: %v", err)
 	}
-	return &channel, nil
+	return channel, nil
 }
 
 // GetAllChannels gets all channels with pagination
-func (db *Database) GetAllChannels(limit, offset int) ([]*models.Channel, error) {
-	var channels []*models.Channel
-	query := "SELECT * FROM channels"
+func (db *Database) GetAllChannels(ctx context.Context, limit, offset int) ([]*models.Channel, error) {
+	query := "SELECT " + channelColumns + " FROM channels WHERE " + liveChannel + " ORDER BY id"
 	if limit > 0 {
-		query += fmt.Sprintf(" LIMIT %d OFFSET %d", limit, offset)
-	}
-
-	rows, err := db.db.Query(query)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	for rows.Next() {
-		var channel models.Channel
-		var watchlistStr, eventlistStr, recentFollowersStr string
-		err := rows.Scan(
-			&channel.ID,
-			&channel.OwnerID,
-			&channel.IsVerified,
-			&channel.Name,
-			&channel.Description,
-			&channel.Avatar,
-			&channel.ChatLink,
-			&channel.IsPublic,
-			&channel.IsHot,
-			&channel.HotExpireAt,
-			&channel.CreatedAt,
-			&channel.UpdatedAt,
-			&watchlistStr,
-			&eventlistStr,
-			&channel.FollowerCount,
-			&recentFollowersStr,
-		)
-		if err != nil {
-			utils.LogError("Error scanning channel", "error", err)
-			return nil, err
-		}
-
-		// Unmarshal Watchlist
-		if err := json.Unmarshal([]byte(watchlistStr), &channel.Watchlist); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal watchlist: %v", err)
-		}
-
-		// Unmarshal Eventlist
-		if err := json.Unmarshal([]byte(eventlistStr), &channel.Eventlist); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal eventlist: %v", err)
-		}
-
-		// Unmarshal RecentFollowers
-		if err := json.Unmarshal([]byte(recentFollowersStr), &channel.RecentFollowers); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal recentFollowers: %v", err)
-		}
-
-		channels = append(channels, &channel)
+		return db.queryChannels(ctx, query+" LIMIT ? OFFSET ?", limit, offset)
 	}
-
-	return channels, nil
+	return db.queryChannels(ctx, query)
 }
 
 // GetChannelByIDs gets multiple channels by their IDs in a single query
-func (db *Database) GetChannelByIDs(channelIDs []string) ([]*models.Channel, error) {
+func (db *Database) GetChannelByIDs(ctx context.Context, channelIDs []string) ([]*models.Channel, error) {
 	if len(channelIDs) == 0 {
 		return nil, nil
 	}
@@ -686,66 +779,11 @@ func (db *Database) GetChannelByIDs(channelIDs []string) ([]*models.Channel, err
 		args[i] = id
 	}
 
-	query := "SELECT * FROM channels WHERE id IN (" + strings.Join(placeholders, ",") + ")"
-	rows, err := db.db.Query(query, args...)
-	if err != nil {
-		return nil, fmt.Errorf("failed to query channels: %v", err)
-	}
-	defer rows.Close()
-
-	var channels []*models.Channel
-	for rows.Next() {
-		channel := &models.Channel{}
-		var watchlistStr, eventlistStr, recentFollowersStr string
-		err := rows.Scan(
-			&channel.ID,
-			&channel.OwnerID,
-			&channel.IsVerified,
-			&channel.Name,
-			&channel.Description,
-			&channel.Avatar,
-			&channel.ChatLink,
-			&channel.IsPublic,
-			&channel.IsHot,
-			&channel.HotExpireAt,
-			&channel.CreatedAt,
-			&channel.UpdatedAt,
-			&watchlistStr,
-			&eventlistStr,
-			&channel.FollowerCount,
-			&recentFollowersStr,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan channel: %v", err)
-		}
-
-		// Unmarshal Watchlist
-		if err := json.Unmarshal([]byte(watchlistStr), &channel.Watchlist); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal watchlist: %v", err)
-		}
-
-		// Unmarshal Eventlist
-		if err := json.Unmarshal([]byte(eventlistStr), &channel.Eventlist); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal eventlist: %v", err)
-		}
-
-		// Unmarshal RecentFollowers
-		if err := json.Unmarshal([]byte(recentFollowersStr), &channel.RecentFollowers); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal recentFollowers: %v", err)
-		}
-
-		channels = append(channels, channel)
-	}
-
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating rows: %v", err)
-	}
-
-	return channels, nil
+	return db.queryChannels(ctx, "SELECT "+channelColumns+" FROM channels WHERE id IN ("+strings.Join(placeholders, ",")+") AND "+liveChannel, args...)
 }
 
 // GetRecentFollowers gets the most recent followers for a channel
-func (db *Database) GetRecentFollowers(channelID string, limit int) ([]int, error) {
+func (db *Database) GetRecentFollowers(ctx context.Context, channelID string, limit int) ([]int, error) {
 	if limit <= 0 {
 		limit = 100 // Default to 100 if not specified
 	}
@@ -758,7 +796,7 @@ func (db *Database) GetRecentFollowers(channelID string, limit int) ([]int, erro
 		LIMIT ?
 	`
 
-	rows, err := db.db.Query(query, channelID, limit)
+	rows, err := db.db.QueryContext(ctx, query, channelID, limit)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query recent followers: %v", err)
 	}
@@ -0,0 +1,75 @@
+package database
+
+import (
+	"strconv"
+	"strings"
+)
+
+// dialect holds the SQL that differs between the database engines behind Database
+type dialect struct {
+	name   string
+	driver string
+	// autocommitDDL is set when each schema change commits implicitly, so a transaction
+	// cannot undo it
+	autocommitDDL bool
+}
+
+var (
+	mysqlDialect    = dialect{name: "mysql", driver: "mysql", autocommitDDL: true}
+	sqliteDialect   = dialect{name: "sqlite", driver: "sqlite"}
+	postgresDialect = dialect{name: "postgres", driver: "pgx"}
+)
+
+// upsert returns the clause that turns an INSERT into an update of the given columns
+// when a row with the same conflict key already exists
+func (d dialect) upsert(conflict []string, update []string) string {
+	sets := make([]string, len(update))
+	switch d.name {
+	case "sqlite":
+		for i, column := range update {
+			sets[i] = column + " = excluded." + column
+		}
+		return "ON CONFLICT(" + strings.Join(conflict, ", ") + ") DO UPDATE SET " + strings.Join(sets, ", ")
+	default:
+		for i, column := range update {
+			sets[i] = column + " = VALUES(" + column + ")"
+		}
+		return "ON DUPLICATE KEY UPDATE " + strings.Join(sets, ", ")
+	}
+}
+
+// insertIgnore returns the clause that turns an INSERT into a no-op, affecting no rows,
+// when a row with the same conflict key already exists
+func (d dialect) insertIgnore(conflict []string) string {
+	if d.name == "mysql" {
+		return "ON DUPLICATE KEY UPDATE " + conflict[0] + " = " + conflict[0]
+	}
+	return "ON CONFLICT(" + strings.Join(conflict, ", ") + ") DO NOTHING"
+}
+
+// forUpdate returns the suffix that locks the selected rows until the transaction ends.
+// SQLite has a single writer and no row locks, so it needs none
+func (d dialect) forUpdate() string {
+	if d.name == "sqlite" {
+		return ""
+	}
+	return " FOR UPDATE"
+}
+
+// rebind rewrites ? placeholders to the $n form PostgreSQL expects
+func (d dialect) rebind(query string) string {
+	if d.name != "postgres" {
+		return query
+	}
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,308 @@
+package database
+
+import (
+	"TwitterMonitor/internal/models"
+	"context"
+)
+
+// Hook runs around one operation of a store wrapped by WithHook. It must call call, with ctx
+// or a context derived from it, and return its error, which it may annotate. op names the
+// store method and write tells whether the operation modifies the store
+type Hook func(ctx context.Context, op string, write bool, call func(context.Context) error) error
+
+// hookStore decorates a store with a hook around each method
+type hookStore struct {
+	store Store
+	hook  Hook
+}
+
+// WithHook runs hook around every method of store but Close. Timeouts, tracing and metrics
+// are hooks, so a new Store method only needs adding here
+func WithHook(store Store, hook Hook) Store {
+	return &hookStore{store: store, hook: hook}
+}
+
+// Unwrap returns the decorated store
+func (s *hookStore) Unwrap() Store {
+	return s.store
+}
+
+func (s *hookStore) InsertOrUpdateChannel(ctx context.Context, channel *models.Channel, authorID int) error {
+	return s.hook(ctx, "InsertOrUpdateChannel", true, func(ctx context.Context) error {
+		return s.store.InsertOrUpdateChannel(ctx, channel, authorID)
+	})
+}
+
+func (s *hookStore) GetChannelsByOwnerID(ctx context.Context, ownerID int) (result []*models.Channel, err error) {
+	err = s.hook(ctx, "GetChannelsByOwnerID", false, func(ctx context.Context) (err error) {
+		result, err = s.store.GetChannelsByOwnerID(ctx, ownerID)
+		return err
+	})
+	return result, err
+}
+
+func (s *hookStore) GetChannelsByID(ctx context.Context, id string) (result []*models.Channel, err error) {
+	err = s.hook(ctx, "GetChannelsByID", false, func(ctx context.Context) (err error) {
+		result, err = s.store.GetChannelsByID(ctx, id)
+		return err
+	})
+	return result, err
+}
+
+func (s *hookStore) GetChannelByID(ctx context.Context, channelID string) (result *models.Channel, err error) {
+	err = s.hook(ctx, "GetChannelByID", false, func(ctx context.Context) (err error) {
+		result, err = s.store.GetChannelByID(ctx, channelID)
+		return err
+	})
+	return result, err
+}
+
+func (s *hookStore) GetChannelByIDs(ctx context.Context, channelIDs []string) (result []*models.Channel, err error) {
+	err = s.hook(ctx, "GetChannelByIDs", false, func(ctx context.Context) (err error) {
+		result, err = s.store.GetChannelByIDs(ctx, channelIDs)
+		return err
+	})
+	return result, err
+}
+
+func (s *hookStore) GetAllChannels(ctx context.Context, limit, offset int) (result []*models.Channel, err error) {
+	err = s.hook(ctx, "GetAllChannels", false, func(ctx context.Context) (err error) {
+		result, err = s.store.GetAllChannels(ctx, limit, offset)
+		return err
+	})
+	return result, err
+}
+
+func (s *hookStore) GetChannelsWatching(ctx context.Context, twitterId string) (result []*models.Channel, err error) {
+	err = s.hook(ctx, "GetChannelsWatching", false, func(ctx context.Context) (err error) {
+		result, err = s.store.GetChannelsWatching(ctx, twitterId)
+		return err
+	})
+	return result, err
+}
+
+func (s *hookStore) GetChannelWatches(ctx context.Context, twitterId string) (result []*models.ChannelWatch, err error) {
+	err = s.hook(ctx, "GetChannelWatches", false, func(ctx context.Context) (err error) {
+		result, err = s.store.GetChannelWatches(ctx, twitterId)
+		return err
+	})
+	return result, err
+}
+
+func (s *hookStore) GetWatchedTwitterIds(ctx context.Context) (result []string, err error) {
+	err = s.hook(ctx, "GetWatchedTwitterIds", false, func(ctx context.Context) (err error) {
+		result, err = s.store.GetWatchedTwitterIds(ctx)
+		return err
+	})
+	return result, err
+}
+
+func (s *hookStore) DeleteChannel(ctx context.Context, channelID string, deletedAt int64) error {
+	return s.hook(ctx, "DeleteChannel", true, func(ctx context.Context) error {
+		return s.store.DeleteChannel(ctx, channelID, deletedAt)
+	})
+}
+
+func (s *hookStore) GetDeletedChannel(ctx context.Context, channelID string) (result *models.Channel, err error) {
+	err = s.hook(ctx, "GetDeletedChannel", false, func(ctx context.Context) (err error) {
+		result, err = s.store.GetDeletedChannel(ctx, channelID)
+		return err
+	})
+	return result, err
+}
+
+func (s *hookStore) RestoreChannel(ctx context.Context, channelID string) error {
+	return s.hook(ctx, "RestoreChannel", true, func(ctx context.Context) error {
+		return s.store.RestoreChannel(ctx, channelID)
+	})
+}
+
+func (s *hookStore) PurgeDeletedChannels(ctx context.Context, deletedBefore int64) (result int, err error) {
+	err = s.hook(ctx, "PurgeDeletedChannels", true, func(ctx context.Context) (err error) {
+		result, err = s.store.PurgeDeletedChannels(ctx, deletedBefore)
+		return err
+	})
+	return result, err
+}
+
+func (s *hookStore) FollowChannel(ctx context.Context, follow *models.Follow) (result bool, err error) {
+	err = s.hook(ctx, "FollowChannel", true, func(ctx context.Context) (err error) {
+		result, err = s.store.FollowChannel(ctx, follow)
+		return err
+	})
+	return result, err
+}
+
+func (s *hookStore) UnfollowChannel(ctx context.Context, userID int, channelID string) (result bool, err error) {
+	err = s.hook(ctx, "UnfollowChannel", true, func(ctx context.Context) (err error) {
+		result, err = s.store.UnfollowChannel(ctx, userID, channelID)
+		return err
+	})
+	return result, err
+}
+
+func (s *hookStore) IsFollowing(ctx context.Context, userID int, channelID string) (result bool, err error) {
+	err = s.hook(ctx, "IsFollowing", false, func(ctx context.Context) (err error) {
+		result, err = s.store.IsFollowing(ctx, userID, channelID)
+		return err
+	})
+	return result, err
+}
+
+func (s *hookStore) GetFollowedChannels(ctx context.Context, userID int) (result []*models.Follow, err error) {
+	err = s.hook(ctx, "GetFollowedChannels", false, func(ctx context.Context) (err error) {
+		result, err = s.store.GetFollowedChannels(ctx, userID)
+		return err
+	})
+	return result, err
+}
+
+func (s *hookStore) GetRecentFollowers(ctx context.Context, channelID string, limit int) (result []int, err error) {
+	err = s.hook(ctx, "GetRecentFollowers", false, func(ctx context.Context) (err error) {
+		result, err = s.store.GetRecentFollowers(ctx, channelID, limit)
+		return err
+	})
+	return result, err
+}
+
+func (s *hookStore) CountFollowers(ctx context.Context, channelID string) (result int, err error) {
+	err = s.hook(ctx, "CountFollowers", false, func(ctx context.Context) (err error) {
+		result, err = s.store.CountFollowers(ctx, channelID)
+		return err
+	})
+	return result, err
+}
+
+func (s *hookStore) RepairFollowerStats(ctx context.Context, channelID string) error {
+	return s.hook(ctx, "RepairFollowerStats", true, func(ctx context.Context) error {
+		return s.store.RepairFollowerStats(ctx, channelID)
+	})
+}
+
+func (s *hookStore) GetChannelVersions(ctx context.Context, channelID string, limit, offset int) (result []*models.ChannelVersion, err error) {
+	err = s.hook(ctx, "GetChannelVersions", false, func(ctx context.Context) (err error) {
+		result, err = s.store.GetChannelVersions(ctx, channelID, limit, offset)
+		return err
+	})
+	return result, err
+}
+
+func (s *hookStore) GetChannelVersion(ctx context.Context, channelID string, version int) (result *models.ChannelVersion, err error) {
+	err = s.hook(ctx, "GetChannelVersion", false, func(ctx context.Context) (err error) {
+		result, err = s.store.GetChannelVersion(ctx, channelID, version)
+		return err
+	})
+	return result, err
+}
+
+func (s *hookStore) GetNotifications(ctx context.Context, userID int, limit, offset int) (result []*models.Notification, err error) {
+	err = s.hook(ctx, "GetNotifications", false, func(ctx context.Context) (err error) {
+		result, err = s.store.GetNotifications(ctx, userID, limit, offset)
+		return err
+	})
+	return result, err
+}
+
+func (s *hookStore) InsertTwitterInfo(ctx context.Context, info *models.TwitterInfo) error {
+	return s.hook(ctx, "InsertTwitterInfo", true, func(ctx context.Context) error {
+		return s.store.InsertTwitterInfo(ctx, info)
+	})
+}
+
+func (s *hookStore) MarkTwitterInfoDeleted(ctx context.Context, tweetsIds []string, deletedAt int64) error {
+	return s.hook(ctx, "MarkTwitterInfoDeleted", true, func(ctx context.Context) error {
+		return s.store.MarkTwitterInfoDeleted(ctx, tweetsIds, deletedAt)
+	})
+}
+
+func (s *hookStore) GetTwitterInfo(ctx context.Context, query ContentQuery) (result []*models.TwitterInfo, err error) {
+	err = s.hook(ctx, "GetTwitterInfo", false, func(ctx context.Context) (err error) {
+		result, err = s.store.GetTwitterInfo(ctx, query)
+		return err
+	})
+	return result, err
+}
+
+func (s *hookStore) GetProfileUpdates(ctx context.Context, twitterId string, limit int) (result []*models.TwitterInfo, err error) {
+	err = s.hook(ctx, "GetProfileUpdates", false, func(ctx context.Context) (err error) {
+		result, err = s.store.GetProfileUpdates(ctx, twitterId, limit)
+		return err
+	})
+	return result, err
+}
+
+func (s *hookStore) GetTweetStats(ctx context.Context, twitterId string) (result *models.TweetStats, err error) {
+	err = s.hook(ctx, "GetTweetStats", false, func(ctx context.Context) (err error) {
+		result, err = s.store.GetTweetStats(ctx, twitterId)
+		return err
+	})
+	return result, err
+}
+
+func (s *hookStore) RecordTwitterHandle(ctx context.Context, twitterId, userName string, seenAt int64) error {
+	return s.hook(ctx, "RecordTwitterHandle", true, func(ctx context.Context) error {
+		return s.store.RecordTwitterHandle(ctx, twitterId, userName, seenAt)
+	})
+}
+
+func (s *hookStore) GetTwitterHandles(ctx context.Context, twitterId string) (result []*models.TwitterHandle, err error) {
+	err = s.hook(ctx, "GetTwitterHandles", false, func(ctx context.Context) (err error) {
+		result, err = s.store.GetTwitterHandles(ctx, twitterId)
+		return err
+	})
+	return result, err
+}
+
+func (s *hookStore) UpsertTwitterAccount(ctx context.Context, account *models.TwitterAccount) error {
+	return s.hook(ctx, "UpsertTwitterAccount", true, func(ctx context.Context) error {
+		return s.store.UpsertTwitterAccount(ctx, account)
+	})
+}
+
+func (s *hookStore) GetTwitterAccounts(ctx context.Context, twitterIds []string) (result map[string]*models.TwitterAccount, err error) {
+	err = s.hook(ctx, "GetTwitterAccounts", false, func(ctx context.Context) (err error) {
+		result, err = s.store.GetTwitterAccounts(ctx, twitterIds)
+		return err
+	})
+	return result, err
+}
+
+func (s *hookStore) SaveRiskScore(ctx context.Context, score *models.RiskScore) error {
+	return s.hook(ctx, "SaveRiskScore", true, func(ctx context.Context) error {
+		return s.store.SaveRiskScore(ctx, score)
+	})
+}
+
+func (s *hookStore) GetRiskScores(ctx context.Context, twitterIds []string) (result map[string]*models.RiskScore, err error) {
+	err = s.hook(ctx, "GetRiskScores", false, func(ctx context.Context) (err error) {
+		result, err = s.store.GetRiskScores(ctx, twitterIds)
+		return err
+	})
+	return result, err
+}
+
+func (s *hookStore) CountChannels(ctx context.Context) (result int, err error) {
+	err = s.hook(ctx, "CountChannels", false, func(ctx context.Context) (err error) {
+		result, err = s.store.CountChannels(ctx)
+		return err
+	})
+	return result, err
+}
+
+func (s *hookStore) CountFollows(ctx context.Context) (result int, err error) {
+	err = s.hook(ctx, "CountFollows", false, func(ctx context.Context) (err error) {
+		result, err = s.store.CountFollows(ctx)
+		return err
+	})
+	return result, err
+}
+
+func (s *hookStore) Ping(ctx context.Context) error {
+	return s.hook(ctx, "Ping", false, func(ctx context.Context) error {
+		return s.store.Ping(ctx)
+	})
+}
+
+func (s *hookStore) Close() error {
+	return s.store.Close()
+}
@@ -0,0 +1,52 @@
+package database
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+// TestWithHookCoversStore calls every Store method on a hooked store and checks that each
+// one but Close went through the hook, with writes flagged
+func TestWithHookCoversStore(t *testing.T) {
+	writes := map[string]bool{
+		"InsertOrUpdateChannel": true, "DeleteChannel": true, "RestoreChannel": true,
+		"PurgeDeletedChannels": true, "FollowChannel": true, "UnfollowChannel": true,
+		"RepairFollowerStats": true, "InsertTwitterInfo": true, "MarkTwitterInfoDeleted": true,
+		"RecordTwitterHandle": true, "UpsertTwitterAccount": true, "SaveRiskScore": true,
+	}
+
+	seen := make(map[string]bool)
+	// The hook skips the call, so the zero arguments never reach the store
+	hooked := WithHook(NewMemoryDatabase(), func(ctx context.Context, op string, write bool, call func(context.Context) error) error {
+		seen[op] = write
+		return nil
+	})
+
+	store := reflect.ValueOf(hooked)
+	storeType := reflect.TypeOf((*Store)(nil)).Elem()
+	for i := 0; i < storeType.NumMethod(); i++ {
+		method := storeType.Method(i)
+		if method.Name == "Close" {
+			continue
+		}
+		fn := store.MethodByName(method.Name)
+		args := make([]reflect.Value, fn.Type().NumIn())
+		args[0] = reflect.ValueOf(context.Background())
+		for j := 1; j < len(args); j++ {
+			args[j] = reflect.Zero(fn.Type().In(j))
+		}
+		fn.Call(args)
+
+		write, ok := seen[method.Name]
+		if !ok {
+			t.Errorf("%s bypasses the hook", method.Name)
+		} else if write != writes[method.Name] {
+			t.Errorf("%s has write = %v, want %v", method.Name, write, writes[method.Name])
+		}
+	}
+
+	if Unwrap(hooked) == hooked {
+		t.Error("Unwrap does not see through the hooked store")
+	}
+}
@@ -0,0 +1,796 @@
+package database
+
+import (
+	"TwitterMonitor/internal/models"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemoryDatabase is an in-memory Store with the same semantics as the MySQL Database,
+// used to run the API in-process without a database server
+type MemoryDatabase struct {
+	mu              sync.RWMutex
+	recentFollowers int
+
+	channels      map[string]*models.Channel
+	versions      map[string][]*models.ChannelVersion // channelId -> versions, oldest first
+	follows       map[string]*models.Follow
+	notifications []*models.Notification
+	twitterInfos  map[string]*models.TwitterInfo // keyed by tweetsId
+	nextInfoID    int
+	handles       map[string]map[string]*models.TwitterHandle // twitterId -> userName -> handle
+	accounts      map[string]*models.TwitterAccount
+	riskScores    map[string]*models.RiskScore
+}
+
+// NewMemoryDatabase creates an empty in-memory store
+func NewMemoryDatabase() *MemoryDatabase {
+	return &MemoryDatabase{
+		recentFollowers: DefaultRecentFollowersLimit,
+		channels:        make(map[string]*models.Channel),
+		versions:        make(map[string][]*models.ChannelVersion),
+		follows:         make(map[string]*models.Follow),
+		twitterInfos:    make(map[string]*models.TwitterInfo),
+		handles:         make(map[string]map[string]*models.TwitterHandle),
+		accounts:        make(map[string]*models.TwitterAccount),
+		riskScores:      make(map[string]*models.RiskScore),
+	}
+}
+
+// Ping always succeeds
+func (m *MemoryDatabase) Ping(ctx context.Context) error {
+	return nil
+}
+
+// Close does nothing; the data lives as long as the store
+func (m *MemoryDatabase) Close() error {
+	return nil
+}
+
+// copyChannel deep-copies a channel through JSON, the same way the MySQL store round-trips
+// the watchlist, eventlist and recentFollowers columns
+func copyChannel(channel *models.Channel) (*models.Channel, error) {
+	data, err := json.Marshal(channel)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal channel: %v", err)
+	}
+	var copied models.Channel
+	if err := json.Unmarshal(data, &copied); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal channel: %v", err)
+	}
+	emptyChannelLists(&copied)
+	return &copied, nil
+}
+
+// sortedChannels returns copies of the live channels matching keep, in primary key order
+func (m *MemoryDatabase) sortedChannels(keep func(*models.Channel) bool) ([]*models.Channel, error) {
+	ids := make([]string, 0, len(m.channels))
+	for id, channel := range m.channels {
+		if channel.DeletedAt == 0 && keep(channel) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+
+	var channels []*models.Channel
+	for _, id := range ids {
+		channel, err := copyChannel(m.channels[id])
+		if err != nil {
+			return nil, err
+		}
+		channels = append(channels, channel)
+	}
+	return channels, nil
+}
+
+// InsertOrUpdateChannel inserts a channel or updates it if it already exists, recording a new
+// version by authorID if the versioned configuration changed
+func (m *MemoryDatabase) InsertOrUpdateChannel(ctx context.Context, channel *models.Channel, authorID int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored, err := copyChannel(channel)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UnixMilli()
+	stored.UpdatedAt = now
+	if existing, ok := m.channels[channel.ID]; ok {
+		// Like ON DUPLICATE KEY UPDATE, the owner, creation time and follower data are kept
+		stored.OwnerID = existing.OwnerID
+		stored.CreatedAt = existing.CreatedAt
+		stored.FollowerCount = existing.FollowerCount
+		stored.RecentFollowers = existing.RecentFollowers
+		stored.DeletedAt = existing.DeletedAt
+	} else {
+		stored.DeletedAt = 0
+		stored.CreatedAt = now
+	}
+	m.channels[channel.ID] = stored
+
+	version := models.NewChannelVersion(stored, authorID, now)
+	versions := m.versions[channel.ID]
+	if len(versions) > 0 && models.DiffChannelVersions(versions[len(versions)-1], version).Empty() {
+		return nil
+	}
+	version.Version = len(versions) + 1
+	m.versions[channel.ID] = append(versions, version)
+	return nil
+}
+
+// copyChannelVersion deep-copies a channel version through JSON
+func copyChannelVersion(version *models.ChannelVersion) (*models.ChannelVersion, error) {
+	data, err := json.Marshal(version)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal channel version: %v", err)
+	}
+	var copied models.ChannelVersion
+	if err := json.Unmarshal(data, &copied); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal channel version: %v", err)
+	}
+	emptyVersionLists(&copied)
+	return &copied, nil
+}
+
+// GetChannelVersions gets the versions of a channel, newest first
+func (m *MemoryDatabase) GetChannelVersions(ctx context.Context, channelID string, limit, offset int) ([]*models.ChannelVersion, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	stored := m.versions[channelID]
+	versions := make([]*models.ChannelVersion, 0, len(stored))
+	for i := len(stored) - 1; i >= 0; i-- {
+		version, err := copyChannelVersion(stored[i])
+		if err != nil {
+			return nil, err
+		}
+		versions = append(versions, version)
+	}
+	return paginate(versions, limit, offset), nil
+}
+
+// GetChannelVersion gets one version of a channel
+func (m *MemoryDatabase) GetChannelVersion(ctx context.Context, channelID string, version int) (*models.ChannelVersion, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	versions := m.versions[channelID]
+	if version < 1 || version > len(versions) {
+		return nil, sql.ErrNoRows
+	}
+	return copyChannelVersion(versions[version-1])
+}
+
+// GetChannelsByOwnerID retrieves channels by OwnerID
+func (m *MemoryDatabase) GetChannelsByOwnerID(ctx context.Context, ownerID int) ([]*models.Channel, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.sortedChannels(func(channel *models.Channel) bool {
+		return channel.OwnerID == ownerID
+	})
+}
+
+// GetChannelsByID retrieves channels by ID
+func (m *MemoryDatabase) GetChannelsByID(ctx context.Context, id string) ([]*models.Channel, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.sortedChannels(func(channel *models.Channel) bool {
+		return channel.ID == id
+	})
+}
+
+// GetChannelByID gets a channel by its ID
+func (m *MemoryDatabase) GetChannelByID(ctx context.Context, channelID string) (*models.Channel, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	channel, ok := m.channels[channelID]
+	if !ok || channel.DeletedAt != 0 {
+		return nil, sql.ErrNoRows
+	}
+	return copyChannel(channel)
+}
+
+// GetChannelByIDs gets multiple channels by their IDs
+func (m *MemoryDatabase) GetChannelByIDs(ctx context.Context, channelIDs []string) ([]*models.Channel, error) {
+	if len(channelIDs) == 0 {
+		return nil, nil
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	wanted := make(map[string]bool, len(channelIDs))
+	for _, id := range channelIDs {
+		wanted[id] = true
+	}
+	return m.sortedChannels(func(channel *models.Channel) bool {
+		return wanted[channel.ID]
+	})
+}
+
+// GetAllChannels gets all channels with pagination
+func (m *MemoryDatabase) GetAllChannels(ctx context.Context, limit, offset int) ([]*models.Channel, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	channels, err := m.sortedChannels(func(*models.Channel) bool { return true })
+	if err != nil {
+		return nil, err
+	}
+	if limit > 0 {
+		channels = paginate(channels, limit, offset)
+	}
+	return channels, nil
+}
+
+// GetChannelsWatching gets the channels whose watchlist contains a Twitter account
+func (m *MemoryDatabase) GetChannelsWatching(ctx context.Context, twitterId string) ([]*models.Channel, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.sortedChannels(func(channel *models.Channel) bool {
+		for _, watch := range channel.Watchlist {
+			if watch.TwitterId == twitterId {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// GetChannelWatches gets the watchlist entries of all live channels watching a Twitter account
+func (m *MemoryDatabase) GetChannelWatches(ctx context.Context, twitterId string) ([]*models.ChannelWatch, error) {
+	channels, err := m.GetChannelsWatching(ctx, twitterId)
+	if err != nil {
+		return nil, err
+	}
+
+	var watches []*models.ChannelWatch
+	for _, channel := range channels {
+		for _, watch := range models.ChannelWatchesFromWatchlist(channel.ID, channel.Watchlist) {
+			if watch.TwitterId == twitterId {
+				watches = append(watches, &watch)
+			}
+		}
+	}
+	return watches, nil
+}
+
+// GetWatchedTwitterIds gets the IDs of the Twitter accounts watched by any live channel
+func (m *MemoryDatabase) GetWatchedTwitterIds(ctx context.Context) ([]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	watched := make(map[string]bool)
+	for _, channel := range m.channels {
+		if channel.DeletedAt != 0 {
+			continue
+		}
+		for _, watch := range models.ChannelWatchesFromWatchlist(channel.ID, channel.Watchlist) {
+			watched[watch.TwitterId] = true
+		}
+	}
+
+	twitterIds := make([]string, 0, len(watched))
+	for twitterId := range watched {
+		twitterIds = append(twitterIds, twitterId)
+	}
+	sort.Strings(twitterIds)
+	return twitterIds, nil
+}
+
+// DeleteChannel marks a channel deleted and notifies its followers, returning sql.ErrNoRows
+// if there is no live channel with that ID
+func (m *MemoryDatabase) DeleteChannel(ctx context.Context, channelID string, deletedAt int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	channel, ok := m.channels[channelID]
+	if !ok || channel.DeletedAt != 0 {
+		return sql.ErrNoRows
+	}
+	channel.DeletedAt = deletedAt
+	m.notifyFollowers(channel, models.NotificationChannelDeleted, deletedAt)
+	return nil
+}
+
+// GetDeletedChannel gets a deleted channel by its ID
+func (m *MemoryDatabase) GetDeletedChannel(ctx context.Context, channelID string) (*models.Channel, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	channel, ok := m.channels[channelID]
+	if !ok || channel.DeletedAt == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return copyChannel(channel)
+}
+
+// RestoreChannel clears the deletion mark of a channel and notifies its followers
+func (m *MemoryDatabase) RestoreChannel(ctx context.Context, channelID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	channel, ok := m.channels[channelID]
+	if !ok || channel.DeletedAt == 0 {
+		return sql.ErrNoRows
+	}
+	channel.DeletedAt = 0
+	m.notifyFollowers(channel, models.NotificationChannelRestored, time.Now().UnixMilli())
+	return nil
+}
+
+// PurgeDeletedChannels removes the channels deleted before deletedBefore together with their
+// follows and versions
+func (m *MemoryDatabase) PurgeDeletedChannels(ctx context.Context, deletedBefore int64) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	purged := 0
+	for id, channel := range m.channels {
+		if channel.DeletedAt == 0 || channel.DeletedAt >= deletedBefore {
+			continue
+		}
+		for followID, follow := range m.follows {
+			if follow.ChannelID == id {
+				delete(m.follows, followID)
+			}
+		}
+		delete(m.channels, id)
+		delete(m.versions, id)
+		purged++
+	}
+	return purged, nil
+}
+
+// notifyFollowers records a notification for every follower of a channel; the caller holds the write lock
+func (m *MemoryDatabase) notifyFollowers(channel *models.Channel, notificationType string, createdAt int64) {
+	for _, follow := range m.follows {
+		if follow.ChannelID != channel.ID {
+			continue
+		}
+		m.notifications = append(m.notifications, &models.Notification{
+			ID:          int64(len(m.notifications) + 1),
+			UserID:      follow.UserID,
+			Type:        notificationType,
+			ChannelID:   channel.ID,
+			ChannelName: channel.Name,
+			CreatedAt:   createdAt,
+		})
+	}
+}
+
+// GetNotifications gets the notifications of a user, newest first
+func (m *MemoryDatabase) GetNotifications(ctx context.Context, userID int, limit, offset int) ([]*models.Notification, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var notifications []*models.Notification
+	for i := len(m.notifications) - 1; i >= 0; i-- {
+		if m.notifications[i].UserID == userID {
+			copied := *m.notifications[i]
+			notifications = append(notifications, &copied)
+		}
+	}
+	return paginate(notifications, limit, offset), nil
+}
+
+// findFollow returns the follow of a user and channel, or nil; the caller holds the lock
+func (m *MemoryDatabase) findFollow(userID int, channelID string) *models.Follow {
+	for _, follow := range m.follows {
+		if follow.UserID == userID && follow.ChannelID == channelID {
+			return follow
+		}
+	}
+	return nil
+}
+
+// refreshRecentFollowers mirrors Database.refreshRecentFollowers; the caller holds the write lock
+func (m *MemoryDatabase) refreshRecentFollowers(channel *models.Channel) {
+	var follows []*models.Follow
+	for _, follow := range m.follows {
+		if follow.ChannelID == channel.ID {
+			follows = append(follows, follow)
+		}
+	}
+	sort.Slice(follows, func(i, j int) bool {
+		return follows[i].CreatedAt > follows[j].CreatedAt
+	})
+
+	followers := []int{}
+	for i, follow := range follows {
+		if i >= m.recentFollowers {
+			break
+		}
+		followers = append(followers, follow.UserID)
+	}
+	channel.RecentFollowers = followers
+}
+
+// FollowChannel creates a follow relationship between a user and a channel, returning false
+// when the user already follows it
+func (m *MemoryDatabase) FollowChannel(ctx context.Context, follow *models.Follow) (bool, error) {
+	follow.CreatedAt = time.Now().UnixMilli()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	channel, ok := m.channels[follow.ChannelID]
+	if !ok || channel.DeletedAt != 0 {
+		return false, sql.ErrNoRows
+	}
+	if m.findFollow(follow.UserID, follow.ChannelID) != nil {
+		return false, nil
+	}
+
+	stored := *follow
+	m.follows[stored.ID] = &stored
+	channel.FollowerCount++
+	m.refreshRecentFollowers(channel)
+	return true, nil
+}
+
+// UnfollowChannel removes a follow relationship between a user and a channel, returning false
+// when the user does not follow it
+func (m *MemoryDatabase) UnfollowChannel(ctx context.Context, userID int, channelID string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	channel, ok := m.channels[channelID]
+	if !ok || channel.DeletedAt != 0 {
+		return false, sql.ErrNoRows
+	}
+	follow := m.findFollow(userID, channelID)
+	if follow == nil {
+		return false, nil
+	}
+
+	delete(m.follows, follow.ID)
+	if channel.FollowerCount > 0 {
+		channel.FollowerCount--
+	}
+	m.refreshRecentFollowers(channel)
+	return true, nil
+}
+
+// CountFollowers counts the follows of a channel
+func (m *MemoryDatabase) CountFollowers(ctx context.Context, channelID string) (int, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	count := 0
+	for _, follow := range m.follows {
+		if follow.ChannelID == channelID {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// CountChannels counts the channels that have not been deleted
+func (m *MemoryDatabase) CountChannels(ctx context.Context) (int, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	count := 0
+	for _, channel := range m.channels {
+		if channel.DeletedAt == 0 {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// CountFollows counts the follows of channels that have not been deleted
+func (m *MemoryDatabase) CountFollows(ctx context.Context) (int, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	count := 0
+	for _, follow := range m.follows {
+		if channel, ok := m.channels[follow.ChannelID]; ok && channel.DeletedAt == 0 {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// RepairFollowerStats recomputes the followerCount and recentFollowers of a channel from its follows
+func (m *MemoryDatabase) RepairFollowerStats(ctx context.Context, channelID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	channel, ok := m.channels[channelID]
+	if !ok || channel.DeletedAt != 0 {
+		return sql.ErrNoRows
+	}
+	channel.FollowerCount = 0
+	for _, follow := range m.follows {
+		if follow.ChannelID == channelID {
+			channel.FollowerCount++
+		}
+	}
+	m.refreshRecentFollowers(channel)
+	return nil
+}
+
+// IsFollowing checks if a user is following a channel
+func (m *MemoryDatabase) IsFollowing(ctx context.Context, userID int, channelID string) (bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, follow := range m.follows {
+		if follow.UserID == userID && follow.ChannelID == channelID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// GetFollowedChannels gets all channels followed by a user, skipping deleted channels
+func (m *MemoryDatabase) GetFollowedChannels(ctx context.Context, userID int) ([]*models.Follow, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var follows []*models.Follow
+	for _, follow := range m.follows {
+		if channel, ok := m.channels[follow.ChannelID]; follow.UserID == userID && ok && channel.DeletedAt == 0 {
+			copied := *follow
+			follows = append(follows, &copied)
+		}
+	}
+	sort.Slice(follows, func(i, j int) bool {
+		return follows[i].ID < follows[j].ID
+	})
+	return follows, nil
+}
+
+// GetRecentFollowers gets the most recent followers for a channel
+func (m *MemoryDatabase) GetRecentFollowers(ctx context.Context, channelID string, limit int) ([]int, error) {
+	if limit <= 0 {
+		limit = 100 // Default to 100 if not specified
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var follows []*models.Follow
+	for _, follow := range m.follows {
+		if follow.ChannelID == channelID {
+			follows = append(follows, follow)
+		}
+	}
+	sort.Slice(follows, func(i, j int) bool {
+		return follows[i].CreatedAt > follows[j].CreatedAt
+	})
+
+	var followers []int
+	for i, follow := range follows {
+		if i >= limit {
+			break
+		}
+		followers = append(followers, follow.UserID)
+	}
+	return followers, nil
+}
+
+// InsertTwitterInfo inserts a Twitter info record or updates it if the tweet was already ingested
+func (m *MemoryDatabase) InsertTwitterInfo(ctx context.Context, info *models.TwitterInfo) error {
+	if info.CreateTime == 0 {
+		info.CreateTime = time.Now().UnixMilli()
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored := *info
+	stored.Addresses = append([]models.ContractAddress(nil), info.Addresses...)
+	if existing, ok := m.twitterInfos[info.TweetsId]; ok {
+		// Like ON DUPLICATE KEY UPDATE, the ID, creation time and deletion mark are kept
+		stored.ID = existing.ID
+		stored.CreateTime = existing.CreateTime
+		stored.DeletedAt = existing.DeletedAt
+	} else {
+		m.nextInfoID++
+		stored.ID = m.nextInfoID
+		stored.DeletedAt = 0
+	}
+	m.twitterInfos[info.TweetsId] = &stored
+	return nil
+}
+
+// MarkTwitterInfoDeleted records that tweets were deleted by their author
+func (m *MemoryDatabase) MarkTwitterInfoDeleted(ctx context.Context, tweetsIds []string, deletedAt int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, id := range tweetsIds {
+		if info, ok := m.twitterInfos[id]; ok && info.DeletedAt == 0 {
+			info.DeletedAt = deletedAt
+		}
+	}
+	return nil
+}
+
+// selectTwitterInfo returns copies of the records matching keep, newest first
+func (m *MemoryDatabase) selectTwitterInfo(keep func(*models.TwitterInfo) bool) []*models.TwitterInfo {
+	var twitterInfos []*models.TwitterInfo
+	for _, info := range m.twitterInfos {
+		if keep(info) {
+			copied := *info
+			copied.Addresses = append([]models.ContractAddress(nil), info.Addresses...)
+			twitterInfos = append(twitterInfos, &copied)
+		}
+	}
+	sort.Slice(twitterInfos, func(i, j int) bool {
+		if twitterInfos[i].CreateTime != twitterInfos[j].CreateTime {
+			return twitterInfos[i].CreateTime > twitterInfos[j].CreateTime
+		}
+		return twitterInfos[i].ID > twitterInfos[j].ID
+	})
+	return twitterInfos
+}
+
+// GetTwitterInfo gets the page of Twitter info selected by a content query
+func (m *MemoryDatabase) GetTwitterInfo(ctx context.Context, query ContentQuery) ([]*models.TwitterInfo, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	twitterInfos := m.selectTwitterInfo(query.matches)
+	return paginate(twitterInfos, query.Limit, query.Offset), nil
+}
+
+// GetProfileUpdates gets the most recent profile update records of a Twitter account, oldest first
+func (m *MemoryDatabase) GetProfileUpdates(ctx context.Context, twitterId string, limit int) ([]*models.TwitterInfo, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	updates := m.selectTwitterInfo(func(info *models.TwitterInfo) bool {
+		return info.TwitterId == twitterId && info.Type == models.TwitterInfoTypeUpdate
+	})
+	updates = paginate(updates, limit, 0)
+	for i, j := 0, len(updates)-1; i < j; i, j = i+1, j-1 {
+		updates[i], updates[j] = updates[j], updates[i]
+	}
+	return updates, nil
+}
+
+// GetTweetStats counts the stored tweets of a Twitter account, those mentioning a CA and those deleted
+func (m *MemoryDatabase) GetTweetStats(ctx context.Context, twitterId string) (*models.TweetStats, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var stats models.TweetStats
+	distinct := make(map[string]bool)
+	for _, info := range m.twitterInfos {
+		if info.TwitterId != twitterId || info.Type != models.TwitterInfoTypeTweet {
+			continue
+		}
+		stats.Total++
+		if info.Address != "" {
+			stats.WithCA++
+			distinct[strings.ToLower(info.Address)] = true
+		}
+		if info.DeletedAt != 0 {
+			stats.Deleted++
+		}
+		for _, addr := range info.Addresses {
+			distinct[strings.ToLower(addr.Address)] = true
+		}
+	}
+	stats.DistinctCA = len(distinct)
+	return &stats, nil
+}
+
+// RecordTwitterHandle records that a Twitter account was seen with a username
+func (m *MemoryDatabase) RecordTwitterHandle(ctx context.Context, twitterId, userName string, seenAt int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	handles, ok := m.handles[twitterId]
+	if !ok {
+		handles = make(map[string]*models.TwitterHandle)
+		m.handles[twitterId] = handles
+	}
+	if handle, ok := handles[userName]; ok {
+		handle.LastSeenAt = seenAt
+		return nil
+	}
+	handles[userName] = &models.TwitterHandle{
+		TwitterId:   twitterId,
+		UserName:    userName,
+		FirstSeenAt: seenAt,
+		LastSeenAt:  seenAt,
+	}
+	return nil
+}
+
+// GetTwitterHandles gets the usernames a Twitter account has been seen with, most recent first
+func (m *MemoryDatabase) GetTwitterHandles(ctx context.Context, twitterId string) ([]*models.TwitterHandle, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var handles []*models.TwitterHandle
+	for _, handle := range m.handles[twitterId] {
+		copied := *handle
+		handles = append(handles, &copied)
+	}
+	sort.Slice(handles, func(i, j int) bool {
+		return handles[i].LastSeenAt > handles[j].LastSeenAt
+	})
+	return handles, nil
+}
+
+// UpsertTwitterAccount inserts or refreshes the cached metadata of a Twitter account
+func (m *MemoryDatabase) UpsertTwitterAccount(ctx context.Context, account *models.TwitterAccount) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored := *account
+	m.accounts[account.TwitterId] = &stored
+	return nil
+}
+
+// GetTwitterAccounts gets the cached metadata of Twitter accounts, keyed by Twitter ID
+func (m *MemoryDatabase) GetTwitterAccounts(ctx context.Context, twitterIds []string) (map[string]*models.TwitterAccount, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	accounts := make(map[string]*models.TwitterAccount)
+	for _, id := range twitterIds {
+		if account, ok := m.accounts[id]; ok {
+			copied := *account
+			accounts[id] = &copied
+		}
+	}
+	return accounts, nil
+}
+
+// SaveRiskScore inserts or replaces the risk score of a Twitter account
+func (m *MemoryDatabase) SaveRiskScore(ctx context.Context, score *models.RiskScore) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored := *score
+	stored.Factors = append([]models.RiskFactor(nil), score.Factors...)
+	m.riskScores[score.TwitterId] = &stored
+	return nil
+}
+
+// GetRiskScores gets the stored risk scores of Twitter accounts, keyed by Twitter ID
+func (m *MemoryDatabase) GetRiskScores(ctx context.Context, twitterIds []string) (map[string]*models.RiskScore, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	scores := make(map[string]*models.RiskScore)
+	for _, id := range twitterIds {
+		if score, ok := m.riskScores[id]; ok {
+			copied := *score
+			copied.Factors = append([]models.RiskFactor(nil), score.Factors...)
+			scores[id] = &copied
+		}
+	}
+	return scores, nil
+}
+
+// paginate applies LIMIT/OFFSET semantics to a slice
+func paginate[T any](items []T, limit, offset int) []T {
+	if offset >= len(items) {
+		return nil
+	}
+	items = items[offset:]
+	if limit < len(items) {
+		items = items[:limit]
+	}
+	return items
+}
@@ -0,0 +1,459 @@
+package database
+
+import (
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed migrations
+var migrationFiles embed.FS
+
+// Migration is one versioned schema change, read from migrations/<dialect>/<version>_<name>.{up,down}.sql
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus reports whether a migration has been applied to the database
+type MigrationStatus struct {
+	Version   int    `json:"version"`
+	Name      string `json:"name"`
+	Applied   bool   `json:"applied"`
+	AppliedAt int64  `json:"appliedAt,omitempty"`
+	// Dirty is set on the migration that failed part way, see DirtySchemaError
+	Dirty bool `json:"dirty,omitempty"`
+}
+
+// SchemaOutdatedError reports a database whose schema is behind the migrations built into the binary
+type SchemaOutdatedError struct {
+	Current int
+	Latest  int
+}
+
+func (e *SchemaOutdatedError) Error() string {
+	return fmt.Sprintf("database schema is at version %d, expected %d; run \"migrate up\"", e.Current, e.Latest)
+}
+
+// DirtySchemaError reports a migration that failed part way on a database that commits DDL
+// statement by statement, like MySQL, leaving the schema somewhere between two versions.
+// Migrations are refused until the schema is repaired by hand and "migrate force" records
+// the version it now matches
+type DirtySchemaError struct {
+	Version   int
+	Direction string
+}
+
+func (e *DirtySchemaError) Error() string {
+	return fmt.Sprintf("database schema is dirty: migration %d failed part way going %s; repair the schema by hand, then run \"migrate force <version>\" with the version it matches", e.Version, e.Direction)
+}
+
+// Migrator applies and reverts the embedded migrations of one dialect, recording applied
+// versions in the schema_migrations table
+type Migrator struct {
+	db         *sql.DB
+	dialect    dialect
+	migrations []Migration
+}
+
+// Migratable is implemented by stores backed by a SQL schema
+type Migratable interface {
+	Migrator() (*Migrator, error)
+}
+
+// NewMigrator loads the embedded migrations of a dialect
+func NewMigrator(db *sql.DB, d dialect) (*Migrator, error) {
+	migrations, err := loadMigrations(d.name)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, dialect: d, migrations: migrations}, nil
+}
+
+// loadMigrations reads and pairs the up and down files of a dialect, sorted by version
+func loadMigrations(dialectName string) ([]Migration, error) {
+	dir := path.Join("migrations", dialectName)
+	entries, err := fs.ReadDir(migrationFiles, dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %v", err)
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		name := entry.Name()
+		var direction string
+		switch {
+		case strings.HasSuffix(name, ".up.sql"):
+			direction = "up"
+		case strings.HasSuffix(name, ".down.sql"):
+			direction = "down"
+		default:
+			continue
+		}
+
+		prefix, rest, ok := strings.Cut(strings.TrimSuffix(name, "."+direction+".sql"), "_")
+		version, err := strconv.Atoi(prefix)
+		if !ok || err != nil {
+			return nil, fmt.Errorf("invalid migration file name: %s", name)
+		}
+		content, err := fs.ReadFile(migrationFiles, path.Join(dir, name))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %v", name, err)
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: rest}
+			byVersion[version] = migration
+		}
+		if direction == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" {
+			return nil, fmt.Errorf("migration %d has no up file", migration.Version)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// Latest returns the version of the newest embedded migration
+func (m *Migrator) Latest() int {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// ensureTable creates schema_migrations and schema_migrations_dirty if they do not exist.
+// schema_migrations_dirty holds the migration being applied or reverted, and keeps it when
+// the migration failed part way
+func (m *Migrator) ensureTable() error {
+	query := `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version   INTEGER      NOT NULL PRIMARY KEY,
+		name      VARCHAR(255) NOT NULL,
+		appliedAt BIGINT       NOT NULL
+	)`
+	if _, err := m.db.Exec(query); err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %v", err)
+	}
+	query = `CREATE TABLE IF NOT EXISTS schema_migrations_dirty (
+		version   INTEGER    NOT NULL PRIMARY KEY,
+		direction VARCHAR(8) NOT NULL,
+		startedAt BIGINT     NOT NULL
+	)`
+	if _, err := m.db.Exec(query); err != nil {
+		return fmt.Errorf("failed to create schema_migrations_dirty: %v", err)
+	}
+	return nil
+}
+
+// dirty returns a *DirtySchemaError when a migration failed part way, nil otherwise
+func (m *Migrator) dirty() (*DirtySchemaError, error) {
+	if err := m.ensureTable(); err != nil {
+		return nil, err
+	}
+
+	var dirty DirtySchemaError
+	err := m.db.QueryRow("SELECT version, direction FROM schema_migrations_dirty ORDER BY version LIMIT 1").
+		Scan(&dirty.Version, &dirty.Direction)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query schema_migrations_dirty: %v", err)
+	}
+	return &dirty, nil
+}
+
+// clean returns a *DirtySchemaError when a migration failed part way
+func (m *Migrator) clean() error {
+	dirty, err := m.dirty()
+	if err != nil {
+		return err
+	}
+	if dirty != nil {
+		return dirty
+	}
+	return nil
+}
+
+// applied returns the applied versions and when they were applied
+func (m *Migrator) applied() (map[int]int64, error) {
+	if err := m.ensureTable(); err != nil {
+		return nil, err
+	}
+
+	rows, err := m.db.Query("SELECT version, appliedAt FROM schema_migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query schema_migrations: %v", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[int]int64)
+	for rows.Next() {
+		var version int
+		var appliedAt int64
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan schema_migrations: %v", err)
+		}
+		applied[version] = appliedAt
+	}
+	return applied, rows.Err()
+}
+
+// Current returns the highest applied version, 0 for an empty database
+func (m *Migrator) Current() (int, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return 0, err
+	}
+	return highestVersion(applied), nil
+}
+
+// highestVersion returns the highest key of applied, 0 when it is empty
+func highestVersion(applied map[int]int64) int {
+	current := 0
+	for version := range applied {
+		if version > current {
+			current = version
+		}
+	}
+	return current
+}
+
+// Status lists every embedded migration and whether it has been applied
+func (m *Migrator) Status() ([]MigrationStatus, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+	dirty, err := m.dirty()
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, len(m.migrations))
+	for i, migration := range m.migrations {
+		appliedAt, ok := applied[migration.Version]
+		statuses[i] = MigrationStatus{
+			Version:   migration.Version,
+			Name:      migration.Name,
+			Applied:   ok,
+			AppliedAt: appliedAt,
+			Dirty:     dirty != nil && dirty.Version == migration.Version,
+		}
+	}
+	return statuses, nil
+}
+
+// Up applies every pending migration in version order and returns the ones it applied
+func (m *Migrator) Up() ([]Migration, error) {
+	if err := m.clean(); err != nil {
+		return nil, err
+	}
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+		err := m.apply(migration.Version, "up", migration.Up,
+			m.dialect.rebind("INSERT INTO schema_migrations (version, name, appliedAt) VALUES (?, ?, ?)"),
+			migration.Version, migration.Name, time.Now().UnixMilli())
+		if err != nil {
+			return done, fmt.Errorf("failed to apply migration %d_%s: %v", migration.Version, migration.Name, err)
+		}
+		done = append(done, migration)
+	}
+	return done, nil
+}
+
+// Down reverts the given number of most recently applied migrations and returns the ones it reverted
+func (m *Migrator) Down(steps int) ([]Migration, error) {
+	if err := m.clean(); err != nil {
+		return nil, err
+	}
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for i := len(m.migrations) - 1; i >= 0 && len(done) < steps; i-- {
+		migration := m.migrations[i]
+		if _, ok := applied[migration.Version]; !ok {
+			continue
+		}
+		if migration.Down == "" {
+			return done, fmt.Errorf("migration %d_%s cannot be reverted", migration.Version, migration.Name)
+		}
+		err := m.apply(migration.Version, "down", migration.Down,
+			m.dialect.rebind("DELETE FROM schema_migrations WHERE version = ?"),
+			migration.Version)
+		if err != nil {
+			return done, fmt.Errorf("failed to revert migration %d_%s: %v", migration.Version, migration.Name, err)
+		}
+		done = append(done, migration)
+	}
+	return done, nil
+}
+
+// apply runs a migration script and the statement recording it, marking the migration dirty
+// while it runs. A failed script keeps the mark on MySQL, which commits DDL implicitly and so
+// may have applied part of the script; elsewhere the transaction undid it all
+func (m *Migrator) apply(version int, direction, script, record string, args ...interface{}) error {
+	_, err := m.db.Exec(m.dialect.rebind("INSERT INTO schema_migrations_dirty (version, direction, startedAt) VALUES (?, ?, ?)"),
+		version, direction, time.Now().UnixMilli())
+	if err != nil {
+		return fmt.Errorf("failed to mark migration dirty: %v", err)
+	}
+
+	err = m.run(script, record, args...)
+	if err != nil && m.dialect.autocommitDDL {
+		return fmt.Errorf("%v; the schema is left dirty, see \"migrate force\"", err)
+	}
+	if _, clearErr := m.db.Exec("DELETE FROM schema_migrations_dirty"); clearErr != nil && err == nil {
+		return fmt.Errorf("failed to clear the dirty mark: %v", clearErr)
+	}
+	return err
+}
+
+// run executes a migration script and the statement recording it in one transaction.
+// MySQL commits DDL implicitly, so there a failed script can leave earlier statements applied
+func (m *Migrator) run(script string, record string, args ...interface{}) error {
+	tx, err := m.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	for _, statement := range splitStatements(script) {
+		if _, err := tx.Exec(statement); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.Exec(record, args...); err != nil {
+		return fmt.Errorf("failed to record migration: %v", err)
+	}
+
+	return tx.Commit()
+}
+
+// Force records the schema as being at version, with every migration up to it applied and
+// none after it, and clears the dirty mark. It changes no table: it is the way out of a dirty
+// schema once it has been repaired by hand to match version, 0 meaning an empty schema
+func (m *Migrator) Force(version int) error {
+	known := version == 0
+	for _, migration := range m.migrations {
+		known = known || migration.Version == version
+	}
+	if !known {
+		return fmt.Errorf("unknown migration version %d", version)
+	}
+	if err := m.ensureTable(); err != nil {
+		return err
+	}
+
+	tx, err := m.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(m.dialect.rebind("DELETE FROM schema_migrations WHERE version > ?"), version); err != nil {
+		return fmt.Errorf("failed to unrecord migrations: %v", err)
+	}
+	record := m.dialect.rebind("INSERT INTO schema_migrations (version, name, appliedAt) VALUES (?, ?, ?) " +
+		m.dialect.insertIgnore([]string{"version"}))
+	now := time.Now().UnixMilli()
+	for _, migration := range m.migrations {
+		if migration.Version > version {
+			break
+		}
+		if _, err := tx.Exec(record, migration.Version, migration.Name, now); err != nil {
+			return fmt.Errorf("failed to record migration %d_%s: %v", migration.Version, migration.Name, err)
+		}
+	}
+	if _, err := tx.Exec("DELETE FROM schema_migrations_dirty"); err != nil {
+		return fmt.Errorf("failed to clear the dirty mark: %v", err)
+	}
+	return tx.Commit()
+}
+
+// Check returns a *DirtySchemaError when a migration failed part way and a
+// *SchemaOutdatedError unless every embedded migration has been applied
+func (m *Migrator) Check() error {
+	if err := m.clean(); err != nil {
+		return err
+	}
+	applied, err := m.applied()
+	if err != nil {
+		return err
+	}
+
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; !ok {
+			return &SchemaOutdatedError{Current: highestVersion(applied), Latest: m.Latest()}
+		}
+	}
+	return nil
+}
+
+// splitStatements splits a migration script on semicolons at the end of a line, dropping
+// comment lines; the drivers do not accept several statements in one Exec
+func splitStatements(script string) []string {
+	var statements []string
+	var current strings.Builder
+	for _, line := range strings.Split(script, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+		current.WriteString(line)
+		current.WriteString("\n")
+		if strings.HasSuffix(trimmed, ";") {
+			statements = append(statements, strings.TrimSuffix(strings.TrimSpace(current.String()), ";"))
+			current.Reset()
+		}
+	}
+	if rest := strings.TrimSpace(current.String()); rest != "" {
+		statements = append(statements, rest)
+	}
+	return statements
+}
+
+// CheckSchema verifies that a SQL-backed store has every embedded migration applied.
+// Stores without a schema, like the in-memory store, always pass
+func CheckSchema(store Store) error {
+	migratable, ok := Unwrap(store).(Migratable)
+	if !ok {
+		return nil
+	}
+	migrator, err := migratable.Migrator()
+	if err != nil {
+		return err
+	}
+	return migrator.Check()
+}
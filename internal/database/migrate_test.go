@@ -0,0 +1,148 @@
+package database
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+// newTestMigrator migrates a new SQLite database to the latest version
+func newTestMigrator(t *testing.T) *Migrator {
+	t.Helper()
+	db, err := NewSQLiteDatabase(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteDatabase: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	migrator, err := db.Migrator()
+	if err != nil {
+		t.Fatalf("Migrator: %v", err)
+	}
+	if _, err := migrator.Up(); err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+	return migrator
+}
+
+// addBrokenMigration appends a migration whose second statement fails
+func addBrokenMigration(m *Migrator) Migration {
+	broken := Migration{
+		Version: m.Latest() + 1,
+		Name:    "broken",
+		Up:      "CREATE TABLE partial (id INTEGER);\nCREATE TABLE partial (id INTEGER);\n",
+		Down:    "DROP TABLE partial;\n",
+	}
+	m.migrations = append(m.migrations, broken)
+	return broken
+}
+
+func TestFailedMigrationRolledBack(t *testing.T) {
+	m := newTestMigrator(t)
+	latest := m.Latest()
+	addBrokenMigration(m)
+
+	if _, err := m.Up(); err == nil {
+		t.Fatal("Up applied a broken migration")
+	}
+	// SQLite undoes the whole script, so nothing is left dirty
+	if err := m.clean(); err != nil {
+		t.Fatalf("clean after rolled back migration: %v", err)
+	}
+	if current, err := m.Current(); err != nil || current != latest {
+		t.Fatalf("Current = %d, %v; want %d", current, err, latest)
+	}
+}
+
+func TestFailedMigrationLeavesSchemaDirty(t *testing.T) {
+	m := newTestMigrator(t)
+	latest := m.Latest()
+	// Commit statement by statement, as MySQL does
+	m.dialect.autocommitDDL = true
+	if _, err := m.db.Exec("CREATE TABLE partial (id INTEGER)"); err != nil {
+		t.Fatal(err)
+	}
+	broken := addBrokenMigration(m)
+
+	if _, err := m.Up(); err == nil {
+		t.Fatal("Up applied a broken migration")
+	}
+
+	var dirty *DirtySchemaError
+	if _, err := m.Up(); !errors.As(err, &dirty) || dirty.Version != broken.Version || dirty.Direction != "up" {
+		t.Fatalf("Up on a dirty schema = %v, want a DirtySchemaError for %d", err, broken.Version)
+	}
+	if _, err := m.Down(1); !errors.As(err, &dirty) {
+		t.Fatalf("Down on a dirty schema = %v, want a DirtySchemaError", err)
+	}
+	if err := m.Check(); !errors.As(err, &dirty) {
+		t.Fatalf("Check on a dirty schema = %v, want a DirtySchemaError", err)
+	}
+	statuses, err := m.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last := statuses[len(statuses)-1]; !last.Dirty || last.Applied {
+		t.Errorf("status of the broken migration = %+v, want dirty and not applied", last)
+	}
+
+	// The partial table is repaired by hand to match the broken migration
+	if err := m.Force(broken.Version); err != nil {
+		t.Fatalf("Force: %v", err)
+	}
+	if err := m.Check(); err != nil {
+		t.Fatalf("Check after force: %v", err)
+	}
+
+	// Forcing back records the later migrations as not applied
+	if err := m.Force(latest); err != nil {
+		t.Fatalf("Force: %v", err)
+	}
+	var outdated *SchemaOutdatedError
+	if err := m.Check(); !errors.As(err, &outdated) || outdated.Current != latest {
+		t.Fatalf("Check after forcing back = %v, want SchemaOutdatedError at %d", err, latest)
+	}
+
+	if err := m.Force(latest + 100); err == nil {
+		t.Error("Force accepted an unknown version")
+	}
+}
+
+func TestFollowCountsMigrationRecountsFollowers(t *testing.T) {
+	m := newTestMigrator(t)
+	if _, err := m.Down(m.Latest() - 6); err != nil {
+		t.Fatalf("Down to 6: %v", err)
+	}
+
+	// Counts drifted and a double click recorded user 2 twice
+	setup := []string{
+		`INSERT INTO channels (id, ownerId, name, followerCount, recentFollowers) VALUES ('c1', 1, 'one', '7', '[9]')`,
+		`INSERT INTO channels (id, ownerId, name, followerCount, recentFollowers) VALUES ('c2', 1, 'two', 'lots', NULL)`,
+		`INSERT INTO follows (id, userId, channelId, createdAt) VALUES ('f1', 1, 'c1', 100)`,
+		`INSERT INTO follows (id, userId, channelId, createdAt) VALUES ('f2', 2, 'c1', 200)`,
+		`INSERT INTO follows (id, userId, channelId, createdAt) VALUES ('f3', 2, 'c1', 201)`,
+	}
+	for _, statement := range setup {
+		if _, err := m.db.Exec(statement); err != nil {
+			t.Fatalf("%s: %v", statement, err)
+		}
+	}
+	if _, err := m.Up(); err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+
+	want := map[string]struct {
+		count  int
+		recent string
+	}{"c1": {2, "[2,1]"}, "c2": {0, "[]"}}
+	for id, want := range want {
+		var count int
+		var recent string
+		if err := m.db.QueryRow("SELECT followerCount, recentFollowers FROM channels WHERE id = ?", id).Scan(&count, &recent); err != nil {
+			t.Fatalf("read %s: %v", id, err)
+		}
+		if count != want.count || recent != want.recent {
+			t.Errorf("%s has followerCount %d and recentFollowers %s, want %d and %s", id, count, recent, want.count, want.recent)
+		}
+	}
+}
@@ -1,4 +1,6 @@
-create table channels
+-- Tables that existed before migrations; IF NOT EXISTS lets databases created from the old
+-- hand-applied twitter.sql adopt the migration history
+create table if not exists channels
 (
     id              varchar(255)         not null
         primary key,
@@ -19,19 +21,17 @@ create table channels
     recentFollowers json                 null
 );
 
-create table follows
+create table if not exists follows
 (
     id        varchar(36) not null
         primary key,
     userId    int         not null,
     channelId varchar(36) not null,
-    createdAt bigint      not null
+    createdAt bigint      not null,
+    index idx_user_channel (userId, channelId)
 );
 
-create index idx_user_channel
-    on follows (userId, channelId);
-
-create table twitter_info
+create table if not exists twitter_info
 (
     id         int auto_increment comment '自增主键，用于唯一标识每条记录'
         primary key,
@@ -46,4 +46,3 @@ create table twitter_info
         unique (tweetsId)
 )
     comment '存储推特相关信息的表' row_format = COMPRESSED;
-
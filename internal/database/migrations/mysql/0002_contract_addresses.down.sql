@@ -0,0 +1,4 @@
+drop table if exists twitter_info_address;
+
+alter table twitter_info
+    drop column cashtags;
@@ -0,0 +1,15 @@
+alter table twitter_info
+    add column cashtags varchar(512) null comment '推文中的 cashtag，逗号分隔' after address;
+
+create table twitter_info_address
+(
+    tweetsId varchar(255) not null comment '推文id',
+    chainId  varchar(64)  not null comment '链',
+    address  varchar(255) not null comment '合约地址',
+    position int          not null comment '在推文中出现的顺序',
+    primary key (tweetsId, chainId, address)
+)
+    comment '推文中提到的全部合约地址';
+
+create index idx_twitter_info_address_address
+    on twitter_info_address (address);
@@ -0,0 +1,13 @@
+alter table twitter_info
+    add column deletedAt bigint null comment '推文被作者删除的时间戳';
+
+create table account_risk
+(
+    twitterId varchar(255) not null
+        primary key comment '推特id',
+    score     int          not null comment '风险分 0-100',
+    level     varchar(16)  not null comment '风险等级 low/medium/high',
+    factors   json         null comment '风险因子',
+    updatedAt bigint       not null comment '计算时间戳'
+)
+    comment '被监控推特账号的风险评分';
@@ -0,0 +1,12 @@
+create table twitter_handles
+(
+    twitterId   varchar(255) not null comment '推特id',
+    userName    varchar(255) not null comment '推特用户名',
+    firstSeenAt bigint       not null comment '首次出现的时间戳',
+    lastSeenAt  bigint       not null comment '最近出现的时间戳',
+    primary key (twitterId, userName)
+)
+    comment '推特用户名变更记录';
+
+create index idx_twitter_handles_user_name
+    on twitter_handles (userName);
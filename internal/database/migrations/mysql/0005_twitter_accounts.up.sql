@@ -0,0 +1,16 @@
+create table twitter_accounts
+(
+    twitterId      varchar(255)         not null
+        primary key comment '推特id',
+    userName       varchar(255)         not null comment '推特用户名',
+    displayName    varchar(255)         null comment '显示名称',
+    avatar         varchar(1024)        null comment '头像',
+    verified       tinyint(1) default 0 not null comment '是否认证',
+    followersCount int        default 0 not null comment '粉丝数',
+    followingCount int        default 0 not null comment '关注数',
+    refreshedAt    bigint               not null comment '最近一次从上游刷新的时间戳'
+)
+    comment '被监控推特账号的元数据缓存';
+
+create index idx_twitter_accounts_refreshed_at
+    on twitter_accounts (refreshedAt);
@@ -0,0 +1,33 @@
+create table channel_watch
+(
+    channelId     varchar(255)         not null comment '频道id',
+    position      int                  not null comment '在 watchlist 中的位置',
+    twitterId     varchar(255)         not null comment '推特id',
+    tweets        tinyint(1) default 0 not null comment '是否推送推文',
+    profileUpdate tinyint(1) default 0 not null comment '是否推送资料更新',
+    follows       tinyint(1) default 0 not null comment '是否推送关注',
+    filterCA      varchar(16)          null comment 'CA 过滤模式 all/any/list',
+    ca            varchar(255)         null comment 'CA 地址',
+    cas           json                 null comment '其他 CA 地址',
+    primary key (channelId, position)
+)
+    comment '频道 watchlist 的展开表，用于按推特id反查频道';
+
+create index idx_channel_watch_twitter_id
+    on channel_watch (twitterId);
+
+insert into channel_watch (channelId, position, twitterId, tweets, profileUpdate, follows, filterCA, ca, cas)
+select c.id, w.position - 1, w.twitterId, coalesce(w.tweets, 0), coalesce(w.profileUpdate, 0), coalesce(w.follows, 0),
+       w.filterCA, w.ca, w.cas
+from channels c,
+     json_table(c.watchlist, '$[*]' columns (
+         position for ordinality,
+         twitterId varchar(255) path '$.twitterId',
+         tweets tinyint(1) path '$.tweets',
+         profileUpdate tinyint(1) path '$.profileUpdate',
+         follows tinyint(1) path '$.follows',
+         filterCA varchar(16) path '$.filterCA',
+         ca varchar(255) path '$.ca',
+         cas json path '$.cas'
+     )) w
+where coalesce(w.twitterId, '') <> '';
@@ -0,0 +1,6 @@
+alter table channels
+    modify followerCount varchar(255) null;
+
+alter table follows
+    add index idx_user_channel (userId, channelId),
+    drop index uk_follows_user_channel;
@@ -0,0 +1,31 @@
+-- Drop duplicate follows, keeping the earliest of each user and channel
+delete f
+from follows f
+         join follows earlier
+              on earlier.userId = f.userId and earlier.channelId = f.channelId
+                  and (earlier.createdAt < f.createdAt or (earlier.createdAt = f.createdAt and earlier.id < f.id));
+
+alter table follows
+    add constraint uk_follows_user_channel unique (userId, channelId),
+    drop index idx_user_channel;
+
+update channels
+set followerCount = '0'
+where followerCount is null
+   or followerCount not regexp '^-?[0-9]+$';
+
+alter table channels
+    modify followerCount int default 0 not null comment '关注人数';
+
+-- Recount followers now that duplicates are gone, and rebuild recentFollowers from the
+-- 50 most recent follows (DefaultRecentFollowersLimit)
+update channels
+set followerCount = (select count(*) from follows where follows.channelId = channels.id);
+
+set session group_concat_max_len = 1048576;
+
+update channels
+set recentFollowers = cast(concat('[', coalesce(
+        (select substring_index(group_concat(follows.userId order by follows.createdAt desc, follows.id desc separator ','), ',', 50)
+         from follows
+         where follows.channelId = channels.id), ''), ']') as json);
@@ -0,0 +1,18 @@
+drop table notifications;
+
+-- Soft-deleted channels would reappear once deletedAt is gone
+delete
+from follows
+where channelId in (select id from channels where deletedAt is not null);
+
+delete
+from channel_watch
+where channelId in (select id from channels where deletedAt is not null);
+
+delete
+from channels
+where deletedAt is not null;
+
+alter table channels
+    drop index idx_channels_deleted_at,
+    drop column deletedAt;
@@ -0,0 +1,21 @@
+-- Hard-deleted channels left their follows behind
+delete
+from follows
+where channelId not in (select id from channels);
+
+alter table channels
+    add column deletedAt bigint null comment '频道被删除的时间戳，为空时频道未删除',
+    add index idx_channels_deleted_at (deletedAt);
+
+create table notifications
+(
+    id          bigint auto_increment
+        primary key,
+    userId      int          not null comment '接收通知的用户',
+    type        varchar(64)  not null comment '通知类型',
+    channelId   varchar(255) not null,
+    channelName varchar(255) not null comment '通知时的频道名称',
+    createdAt   bigint       not null,
+    index idx_notifications_user (userId, createdAt)
+)
+    comment '用户通知';
@@ -0,0 +1 @@
+drop table channel_versions;
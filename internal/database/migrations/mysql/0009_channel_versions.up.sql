@@ -0,0 +1,19 @@
+create table channel_versions
+(
+    channelId   varchar(255)         not null,
+    version     int                  not null comment '频道内递增的版本号',
+    authorId    int                  not null comment '做出修改的用户',
+    createdAt   bigint               not null,
+    name        varchar(255)         not null,
+    description text                 null,
+    isPublic    tinyint(1) default 0 not null,
+    watchlist   json                 null,
+    eventlist   json                 null,
+    primary key (channelId, version)
+)
+    comment '频道配置的历史版本';
+
+-- The current configuration of every channel becomes its first version
+insert into channel_versions (channelId, version, authorId, createdAt, name, description, isPublic, watchlist, eventlist)
+select id, 1, ownerId, coalesce(updatedAt, createdAt, 0), name, description, coalesce(isPublic, 0), watchlist, eventlist
+from channels;
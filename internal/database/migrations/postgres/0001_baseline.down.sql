@@ -0,0 +1,3 @@
+drop table if exists twitter_info;
+drop table if exists follows;
+drop table if exists channels;
@@ -0,0 +1,52 @@
+create table channels
+(
+    id               text   not null primary key,
+    owner_id         bigint not null,
+    is_verified      boolean default false,
+    name             text   not null,
+    description      text,
+    avatar           text,
+    chat_link        text,
+    is_public        boolean default false,
+    is_hot           boolean default false,
+    hot_expire_at    text,
+    created_at       bigint,
+    updated_at       bigint,
+    watchlist        jsonb,
+    eventlist        jsonb,
+    follower_count   text,
+    recent_followers jsonb
+);
+
+-- Reverse lookup of the channels watching a Twitter account: watchlist @> '[{"twitterId": "..."}]'
+create index idx_channels_watchlist
+    on channels using gin (watchlist jsonb_path_ops);
+
+create table follows
+(
+    id         text   not null primary key,
+    user_id    bigint not null,
+    channel_id text   not null,
+    created_at bigint not null
+);
+
+create index idx_follows_user_channel
+    on follows (user_id, channel_id);
+
+create index idx_follows_channel_id
+    on follows (channel_id);
+
+create table twitter_info
+(
+    id          bigserial primary key,
+    tweets_id   text     not null unique,
+    twitter_id  text     not null,
+    content     text,
+    chain_id    text,
+    address     text,
+    create_time bigint,
+    type        smallint not null
+);
+
+create index idx_twitter_info_twitter_type_time
+    on twitter_info (twitter_id, type, create_time desc);
@@ -0,0 +1,14 @@
+alter table twitter_info
+    add column cashtags text;
+
+create table twitter_info_address
+(
+    tweets_id text   not null,
+    chain_id  text   not null,
+    address   text   not null,
+    position  bigint not null,
+    primary key (tweets_id, chain_id, address)
+);
+
+create index idx_twitter_info_address_address
+    on twitter_info_address (lower(address));
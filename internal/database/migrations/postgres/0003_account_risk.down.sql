@@ -0,0 +1,4 @@
+drop table if exists account_risk;
+
+alter table twitter_info
+    drop column deleted_at;
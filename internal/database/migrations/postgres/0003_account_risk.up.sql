@@ -0,0 +1,11 @@
+alter table twitter_info
+    add column deleted_at bigint;
+
+create table account_risk
+(
+    twitter_id text   not null primary key,
+    score      bigint not null,
+    level      text   not null,
+    factors    jsonb,
+    updated_at bigint not null
+);
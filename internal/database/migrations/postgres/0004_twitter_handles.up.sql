@@ -0,0 +1,11 @@
+create table twitter_handles
+(
+    twitter_id    text   not null,
+    user_name     text   not null,
+    first_seen_at bigint not null,
+    last_seen_at  bigint not null,
+    primary key (twitter_id, user_name)
+);
+
+create index idx_twitter_handles_user_name
+    on twitter_handles (lower(user_name));
@@ -0,0 +1,14 @@
+create table twitter_accounts
+(
+    twitter_id      text    not null primary key,
+    user_name       text    not null,
+    display_name    text,
+    avatar          text,
+    verified        boolean not null default false,
+    followers_count bigint  not null default 0,
+    following_count bigint  not null default 0,
+    refreshed_at    bigint  not null
+);
+
+create index idx_twitter_accounts_refreshed_at
+    on twitter_accounts (refreshed_at);
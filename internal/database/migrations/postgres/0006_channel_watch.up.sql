@@ -0,0 +1,26 @@
+create table channel_watch
+(
+    channel_id     text    not null,
+    position       bigint  not null,
+    twitter_id     text    not null,
+    tweets         boolean not null default false,
+    profile_update boolean not null default false,
+    follows        boolean not null default false,
+    filter_ca      text,
+    ca             text,
+    cas            jsonb,
+    primary key (channel_id, position)
+);
+
+create index idx_channel_watch_twitter_id
+    on channel_watch (twitter_id);
+
+insert into channel_watch (channel_id, position, twitter_id, tweets, profile_update, follows, filter_ca, ca, cas)
+select c.id, w.ordinality - 1, w.value ->> 'twitterId',
+       coalesce((w.value ->> 'tweets')::boolean, false), coalesce((w.value ->> 'profileUpdate')::boolean, false),
+       coalesce((w.value ->> 'follows')::boolean, false),
+       w.value ->> 'filterCA', w.value ->> 'ca', w.value -> 'cas'
+from channels c
+         cross join lateral jsonb_array_elements(case when jsonb_typeof(c.watchlist) = 'array' then c.watchlist else '[]'::jsonb end)
+    with ordinality as w(value, ordinality)
+where coalesce(w.value ->> 'twitterId', '') <> '';
@@ -0,0 +1,13 @@
+alter table channels
+    alter column follower_count drop not null;
+
+alter table channels
+    alter column follower_count drop default;
+
+alter table channels
+    alter column follower_count type text using follower_count::text;
+
+drop index if exists uk_follows_user_channel;
+
+create index idx_follows_user_channel
+    on follows (user_id, channel_id);
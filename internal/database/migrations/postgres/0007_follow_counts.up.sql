@@ -0,0 +1,36 @@
+-- Drop duplicate follows, keeping the earliest of each user and channel
+delete
+from follows f
+    using follows earlier
+where earlier.user_id = f.user_id
+  and earlier.channel_id = f.channel_id
+  and (earlier.created_at < f.created_at or (earlier.created_at = f.created_at and earlier.id < f.id));
+
+drop index if exists idx_follows_user_channel;
+
+create unique index uk_follows_user_channel
+    on follows (user_id, channel_id);
+
+alter table channels
+    alter column follower_count type bigint
+        using case when follower_count ~ '^-?[0-9]+$' then follower_count::bigint else 0 end;
+
+alter table channels
+    alter column follower_count set default 0;
+
+alter table channels
+    alter column follower_count set not null;
+
+-- Recount followers now that duplicates are gone, and rebuild recent_followers from the
+-- 50 most recent follows (DefaultRecentFollowersLimit)
+update channels
+set follower_count = (select count(*) from follows where follows.channel_id = channels.id);
+
+update channels
+set recent_followers = coalesce(
+        (select jsonb_agg(recent.user_id order by recent.created_at desc, recent.id desc)
+         from (select user_id, created_at, id
+               from follows
+               where follows.channel_id = channels.id
+               order by created_at desc, id desc
+               limit 50) recent), '[]'::jsonb);
@@ -0,0 +1,17 @@
+drop table notifications;
+
+-- Soft-deleted channels would reappear once deleted_at is gone
+delete
+from follows
+where channel_id in (select id from channels where deleted_at is not null);
+
+delete
+from channel_watch
+where channel_id in (select id from channels where deleted_at is not null);
+
+delete
+from channels
+where deleted_at is not null;
+
+alter table channels
+    drop column deleted_at;
@@ -0,0 +1,23 @@
+-- Hard-deleted channels left their follows behind
+delete
+from follows
+where channel_id not in (select id from channels);
+
+alter table channels
+    add column deleted_at bigint;
+
+create index idx_channels_deleted_at
+    on channels (deleted_at);
+
+create table notifications
+(
+    id           bigserial primary key,
+    user_id      bigint not null,
+    type         text   not null,
+    channel_id   text   not null,
+    channel_name text   not null,
+    created_at   bigint not null
+);
+
+create index idx_notifications_user
+    on notifications (user_id, created_at);
@@ -0,0 +1,18 @@
+create table channel_versions
+(
+    channel_id  text    not null,
+    version     bigint  not null,
+    author_id   bigint  not null,
+    created_at  bigint  not null,
+    name        text    not null,
+    description text,
+    is_public   boolean not null default false,
+    watchlist   jsonb,
+    eventlist   jsonb,
+    primary key (channel_id, version)
+);
+
+-- The current configuration of every channel becomes its first version
+insert into channel_versions (channel_id, version, author_id, created_at, name, description, is_public, watchlist, eventlist)
+select id, 1, owner_id, coalesce(updated_at, created_at, 0), name, description, coalesce(is_public, false), watchlist, eventlist
+from channels;
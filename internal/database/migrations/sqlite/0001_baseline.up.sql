@@ -0,0 +1,42 @@
+create table channels
+(
+    id              text    not null primary key,
+    ownerId         integer not null,
+    isVerified      integer default 0,
+    name            text    not null,
+    description     text,
+    avatar          text,
+    chatLink        text,
+    isPublic        integer default 0,
+    isHot           integer default 0,
+    hotExpireAt     text,
+    createdAt       integer,
+    updatedAt       integer,
+    watchlist       text,
+    eventlist       text,
+    followerCount   text,
+    recentFollowers text
+);
+
+create table follows
+(
+    id        text    not null primary key,
+    userId    integer not null,
+    channelId text    not null,
+    createdAt integer not null
+);
+
+create index idx_user_channel
+    on follows (userId, channelId);
+
+create table twitter_info
+(
+    id         integer primary key autoincrement,
+    tweetsId   text    not null unique,
+    twitterId  text    not null,
+    content    text,
+    chainId    text,
+    address    text collate nocase,
+    createTime integer,
+    type       integer not null
+);
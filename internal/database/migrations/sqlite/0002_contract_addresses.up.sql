@@ -0,0 +1,14 @@
+alter table twitter_info
+    add column cashtags text;
+
+create table twitter_info_address
+(
+    tweetsId text    not null,
+    chainId  text    not null,
+    address  text    not null collate nocase,
+    position integer not null,
+    primary key (tweetsId, chainId, address)
+);
+
+create index idx_twitter_info_address_address
+    on twitter_info_address (address);
@@ -0,0 +1,11 @@
+alter table twitter_info
+    add column deletedAt integer;
+
+create table account_risk
+(
+    twitterId text    not null primary key,
+    score     integer not null,
+    level     text    not null,
+    factors   text,
+    updatedAt integer not null
+);
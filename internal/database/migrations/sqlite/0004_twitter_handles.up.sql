@@ -0,0 +1,11 @@
+create table twitter_handles
+(
+    twitterId   text    not null,
+    userName    text    not null collate nocase,
+    firstSeenAt integer not null,
+    lastSeenAt  integer not null,
+    primary key (twitterId, userName)
+);
+
+create index idx_twitter_handles_user_name
+    on twitter_handles (userName);
@@ -0,0 +1,14 @@
+create table twitter_accounts
+(
+    twitterId      text    not null primary key,
+    userName       text    not null,
+    displayName    text,
+    avatar         text,
+    verified       integer default 0 not null,
+    followersCount integer default 0 not null,
+    followingCount integer default 0 not null,
+    refreshedAt    integer not null
+);
+
+create index idx_twitter_accounts_refreshed_at
+    on twitter_accounts (refreshedAt);
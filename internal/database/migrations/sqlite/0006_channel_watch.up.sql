@@ -0,0 +1,24 @@
+create table channel_watch
+(
+    channelId     text    not null,
+    position      integer not null,
+    twitterId     text    not null,
+    tweets        integer default 0 not null,
+    profileUpdate integer default 0 not null,
+    follows       integer default 0 not null,
+    filterCA      text,
+    ca            text collate nocase,
+    cas           text,
+    primary key (channelId, position)
+);
+
+create index idx_channel_watch_twitter_id
+    on channel_watch (twitterId);
+
+insert into channel_watch (channelId, position, twitterId, tweets, profileUpdate, follows, filterCA, ca, cas)
+select c.id, cast(w.key as integer), json_extract(w.value, '$.twitterId'),
+       coalesce(json_extract(w.value, '$.tweets'), 0), coalesce(json_extract(w.value, '$.profileUpdate'), 0),
+       coalesce(json_extract(w.value, '$.follows'), 0),
+       json_extract(w.value, '$.filterCA'), json_extract(w.value, '$.ca'), json_extract(w.value, '$.cas')
+from channels c, json_each(c.watchlist) w
+where coalesce(json_extract(w.value, '$.twitterId'), '') <> '';
@@ -0,0 +1,34 @@
+create table channels_old
+(
+    id              text    not null primary key,
+    ownerId         integer not null,
+    isVerified      integer default 0,
+    name            text    not null,
+    description     text,
+    avatar          text,
+    chatLink        text,
+    isPublic        integer default 0,
+    isHot           integer default 0,
+    hotExpireAt     text,
+    createdAt       integer,
+    updatedAt       integer,
+    watchlist       text,
+    eventlist       text,
+    followerCount   text,
+    recentFollowers text
+);
+
+insert into channels_old
+select id, ownerId, isVerified, name, description, avatar, chatLink, isPublic, isHot, hotExpireAt,
+       createdAt, updatedAt, watchlist, eventlist, cast(followerCount as text), recentFollowers
+from channels;
+
+drop table channels;
+
+alter table channels_old
+    rename to channels;
+
+drop index uk_follows_user_channel;
+
+create index idx_user_channel
+    on follows (userId, channelId);
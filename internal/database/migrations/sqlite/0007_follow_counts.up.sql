@@ -0,0 +1,52 @@
+-- Drop duplicate follows, keeping the first inserted of each user and channel
+delete from follows
+where rowid not in (select min(rowid) from follows group by userId, channelId);
+
+drop index idx_user_channel;
+
+create unique index uk_follows_user_channel
+    on follows (userId, channelId);
+
+-- SQLite cannot change a column type, so channels is rebuilt with an integer followerCount
+create table channels_new
+(
+    id              text    not null primary key,
+    ownerId         integer not null,
+    isVerified      integer default 0,
+    name            text    not null,
+    description     text,
+    avatar          text,
+    chatLink        text,
+    isPublic        integer default 0,
+    isHot           integer default 0,
+    hotExpireAt     text,
+    createdAt       integer,
+    updatedAt       integer,
+    watchlist       text,
+    eventlist       text,
+    followerCount   integer default 0 not null,
+    recentFollowers text
+);
+
+insert into channels_new
+select id, ownerId, isVerified, name, description, avatar, chatLink, isPublic, isHot, hotExpireAt,
+       createdAt, updatedAt, watchlist, eventlist, coalesce(cast(followerCount as integer), 0), recentFollowers
+from channels;
+
+drop table channels;
+
+alter table channels_new
+    rename to channels;
+
+-- Recount followers now that duplicates are gone, and rebuild recentFollowers from the
+-- 50 most recent follows (DefaultRecentFollowersLimit)
+update channels
+set followerCount = (select count(*) from follows where follows.channelId = channels.id);
+
+update channels
+set recentFollowers = (select json_group_array(recent.userId)
+                       from (select userId
+                             from follows
+                             where follows.channelId = channels.id
+                             order by createdAt desc, rowid desc
+                             limit 50) recent);
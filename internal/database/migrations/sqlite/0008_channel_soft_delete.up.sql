@@ -0,0 +1,23 @@
+-- Hard-deleted channels left their follows behind
+delete
+from follows
+where channelId not in (select id from channels);
+
+alter table channels
+    add column deletedAt integer;
+
+create index idx_channels_deleted_at
+    on channels (deletedAt);
+
+create table notifications
+(
+    id          integer primary key autoincrement,
+    userId      integer not null,
+    type        text    not null,
+    channelId   text    not null,
+    channelName text    not null,
+    createdAt   integer not null
+);
+
+create index idx_notifications_user
+    on notifications (userId, createdAt);
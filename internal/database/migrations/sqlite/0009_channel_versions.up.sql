@@ -0,0 +1,18 @@
+create table channel_versions
+(
+    channelId   text    not null,
+    version     integer not null,
+    authorId    integer not null,
+    createdAt   integer not null,
+    name        text    not null,
+    description text,
+    isPublic    integer default 0 not null,
+    watchlist   text,
+    eventlist   text,
+    primary key (channelId, version)
+);
+
+-- The current configuration of every channel becomes its first version
+insert into channel_versions (channelId, version, authorId, createdAt, name, description, isPublic, watchlist, eventlist)
+select id, 1, ownerId, coalesce(updatedAt, createdAt, 0), name, description, coalesce(isPublic, 0), watchlist, eventlist
+from channels;
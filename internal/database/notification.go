@@ -0,0 +1,54 @@
+package database
+
+import (
+	"TwitterMonitor/internal/models"
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// notifyFollowers records a notification of the given type for every follower of a channel within tx
+func (db *Database) notifyFollowers(ctx context.Context, tx *sql.Tx, channelID, notificationType string, createdAt int64) error {
+	query := `INSERT INTO notifications (userId, type, channelId, channelName, createdAt)
+	          SELECT f.userId, ?, c.id, c.name, ?
+	          FROM follows f
+	          JOIN channels c ON c.id = f.channelId
+	          WHERE f.channelId = ?`
+	if _, err := tx.ExecContext(ctx, query, notificationType, createdAt, channelID); err != nil {
+		return fmt.Errorf("failed to notify followers: %v", err)
+	}
+	return nil
+}
+
+// GetNotifications gets the notifications of a user, newest first
+func (db *Database) GetNotifications(ctx context.Context, userID int, limit, offset int) ([]*models.Notification, error) {
+	query := `SELECT id, userId, type, channelId, channelName, createdAt
+	          FROM notifications
+	          WHERE userId = ?
+	          ORDER BY createdAt DESC, id DESC
+	          LIMIT ? OFFSET ?`
+	rows, err := db.db.QueryContext(ctx, query, userID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query notifications: %v", err)
+	}
+	defer rows.Close()
+
+	var notifications []*models.Notification
+	for rows.Next() {
+		var notification models.Notification
+		err := rows.Scan(
+			&notification.ID,
+			&notification.UserID,
+			&notification.Type,
+			&notification.ChannelID,
+			&notification.ChannelName,
+			&notification.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan notification: %v", err)
+		}
+		notifications = append(notifications, &notification)
+	}
+
+	return notifications, rows.Err()
+}
@@ -0,0 +1,778 @@
+package database
+
+import (
+	"TwitterMonitor/internal/models"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/logger"
+)
+
+// PostgresDatabase is a Store backed by PostgreSQL through gorm. The watchlist, eventlist,
+// recentFollowers and risk factor columns are native jsonb
+type PostgresDatabase struct {
+	db              *gorm.DB
+	recentFollowers int
+}
+
+// twitterInfoAddress is a row of twitter_info_address
+type twitterInfoAddress struct {
+	TweetsId string `gorm:"primaryKey"`
+	ChainId  string `gorm:"primaryKey"`
+	Address  string `gorm:"primaryKey"`
+	Position int    `gorm:"not null"`
+}
+
+// TableName keeps the table name used by the SQL schema
+func (twitterInfoAddress) TableName() string {
+	return "twitter_info_address"
+}
+
+// NewPostgresDatabase creates a new PostgreSQL connection from a postgres:// URL or key=value DSN
+func NewPostgresDatabase(dsn string) (*PostgresDatabase, error) {
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Warn),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to PostgreSQL: %v", err)
+	}
+
+	return &PostgresDatabase{db: db, recentFollowers: DefaultRecentFollowersLimit}, nil
+}
+
+// Migrator returns the schema migrator of the database
+func (p *PostgresDatabase) Migrator() (*Migrator, error) {
+	db, err := p.db.DB()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get PostgreSQL connection: %v", err)
+	}
+	return NewMigrator(db, postgresDialect)
+}
+
+// Ping checks that the database is reachable
+func (p *PostgresDatabase) Ping(ctx context.Context) error {
+	db, err := p.db.DB()
+	if err != nil {
+		return fmt.Errorf("failed to get PostgreSQL connection: %v", err)
+	}
+	return db.PingContext(ctx)
+}
+
+// PoolStats returns the statistics of the connection pool
+func (p *PostgresDatabase) PoolStats() sql.DBStats {
+	db, err := p.db.DB()
+	if err != nil {
+		return sql.DBStats{}
+	}
+	return db.Stats()
+}
+
+// Close closes the connection pool, waiting for running queries to finish
+func (p *PostgresDatabase) Close() error {
+	db, err := p.db.DB()
+	if err != nil {
+		return fmt.Errorf("failed to get PostgreSQL connection: %v", err)
+	}
+	return db.Close()
+}
+
+// InsertOrUpdateChannel inserts a channel or updates it if it already exists, recording a new
+// version by authorID if the versioned configuration changed
+func (p *PostgresDatabase) InsertOrUpdateChannel(ctx context.Context, channel *models.Channel, authorID int) error {
+	now := time.Now().UnixMilli()
+	row := *channel
+	row.CreatedAt = now
+	row.UpdatedAt = now
+
+	return p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Omit("deleted_at").Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "id"}},
+			DoUpdates: clause.AssignmentColumns([]string{
+				"is_verified", "name", "description", "avatar", "chat_link", "is_public", "is_hot", "hot_expire_at",
+				"updated_at", "watchlist", "eventlist",
+			}),
+		}).Create(&row).Error
+		if err != nil {
+			return fmt.Errorf("failed to insert or update channel: %v", err)
+		}
+
+		if err := tx.Where("channel_id = ?", channel.ID).Delete(&models.ChannelWatch{}).Error; err != nil {
+			return fmt.Errorf("failed to clear channel watches: %v", err)
+		}
+		if watches := models.ChannelWatchesFromWatchlist(channel.ID, channel.Watchlist); len(watches) > 0 {
+			if err := tx.Create(&watches).Error; err != nil {
+				return fmt.Errorf("failed to insert channel watch: %v", err)
+			}
+		}
+		return recordChannelVersion(tx, models.NewChannelVersion(channel, authorID, now))
+	})
+}
+
+// recordChannelVersion stores version as the next version of its channel within tx, unless it
+// does not change the configuration of the latest version
+func recordChannelVersion(tx *gorm.DB, version *models.ChannelVersion) error {
+	var latest models.ChannelVersion
+	err := tx.Where("channel_id = ?", version.ChannelID).Order("version DESC").Take(&latest).Error
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		version.Version = 1
+	case err != nil:
+		return fmt.Errorf("failed to query latest channel version: %v", err)
+	case models.DiffChannelVersions(&latest, version).Empty():
+		return nil
+	default:
+		version.Version = latest.Version + 1
+	}
+
+	if err := tx.Create(version).Error; err != nil {
+		return fmt.Errorf("failed to insert channel version: %v", err)
+	}
+	return nil
+}
+
+// GetChannelVersions gets the versions of a channel, newest first
+func (p *PostgresDatabase) GetChannelVersions(ctx context.Context, channelID string, limit, offset int) ([]*models.ChannelVersion, error) {
+	var versions []*models.ChannelVersion
+	err := p.db.WithContext(ctx).Where("channel_id = ?", channelID).
+		Order("version DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&versions).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to query channel versions: %v", err)
+	}
+	for _, version := range versions {
+		emptyVersionLists(version)
+	}
+	return versions, nil
+}
+
+// GetChannelVersion gets one version of a channel, returning sql.ErrNoRows if it does not exist
+func (p *PostgresDatabase) GetChannelVersion(ctx context.Context, channelID string, version int) (*models.ChannelVersion, error) {
+	var channelVersion models.ChannelVersion
+	err := p.db.WithContext(ctx).Where("channel_id = ? AND version = ?", channelID, version).Take(&channelVersion).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, sql.ErrNoRows
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query channel version: %v", err)
+	}
+	emptyVersionLists(&channelVersion)
+	return &channelVersion, nil
+}
+
+// liveChannels scopes a channel query to channels that have not been deleted
+func liveChannels(query *gorm.DB) *gorm.DB {
+	return query.Where("deleted_at IS NULL")
+}
+
+// findChannels runs a query for live channels in primary key order
+func (p *PostgresDatabase) findChannels(query *gorm.DB) ([]*models.Channel, error) {
+	var channels []*models.Channel
+	if err := query.Scopes(liveChannels).Order("id").Find(&channels).Error; err != nil {
+		return nil, fmt.Errorf("failed to query channels: %v", err)
+	}
+	for _, channel := range channels {
+		emptyChannelLists(channel)
+	}
+	return channels, nil
+}
+
+// GetChannelsByOwnerID retrieves channels by OwnerID
+func (p *PostgresDatabase) GetChannelsByOwnerID(ctx context.Context, ownerID int) ([]*models.Channel, error) {
+	return p.findChannels(p.db.WithContext(ctx).Where("owner_id = ?", ownerID))
+}
+
+// GetChannelsByID retrieves channels by ID
+func (p *PostgresDatabase) GetChannelsByID(ctx context.Context, id string) ([]*models.Channel, error) {
+	return p.findChannels(p.db.WithContext(ctx).Where("id = ?", id))
+}
+
+// GetChannelByID gets a channel by its ID
+func (p *PostgresDatabase) GetChannelByID(ctx context.Context, channelID string) (*models.Channel, error) {
+	var channel models.Channel
+	err := p.db.WithContext(ctx).Scopes(liveChannels).Where("id = ?", channelID).Take(&channel).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, sql.ErrNoRows
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query channel: %v", err)
+	}
+	emptyChannelLists(&channel)
+	return &channel, nil
+}
+
+// GetChannelByIDs gets multiple channels by their IDs in a single query
+func (p *PostgresDatabase) GetChannelByIDs(ctx context.Context, channelIDs []string) ([]*models.Channel, error) {
+	if len(channelIDs) == 0 {
+		return nil, nil
+	}
+	return p.findChannels(p.db.WithContext(ctx).Where("id IN ?", channelIDs))
+}
+
+// GetAllChannels gets all channels with pagination
+func (p *PostgresDatabase) GetAllChannels(ctx context.Context, limit, offset int) ([]*models.Channel, error) {
+	query := p.db.WithContext(ctx)
+	if limit > 0 {
+		query = query.Limit(limit).Offset(offset)
+	}
+	return p.findChannels(query)
+}
+
+// GetChannelsWatching gets the channels whose watchlist contains a Twitter account, using the GIN index on watchlist
+func (p *PostgresDatabase) GetChannelsWatching(ctx context.Context, twitterId string) ([]*models.Channel, error) {
+	filter, err := json.Marshal([]map[string]string{{"twitterId": twitterId}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal watchlist filter: %v", err)
+	}
+	return p.findChannels(p.db.WithContext(ctx).Where("watchlist @> ?::jsonb", string(filter)))
+}
+
+// GetChannelWatches gets the watchlist entries of all live channels watching a Twitter account
+func (p *PostgresDatabase) GetChannelWatches(ctx context.Context, twitterId string) ([]*models.ChannelWatch, error) {
+	var watches []*models.ChannelWatch
+	live := p.db.WithContext(ctx).Model(&models.Channel{}).Scopes(liveChannels).Select("id")
+	err := p.db.WithContext(ctx).Where("twitter_id = ? AND channel_id IN (?)", twitterId, live).Order("channel_id, position").Find(&watches).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to query channel watches: %v", err)
+	}
+	return watches, nil
+}
+
+// GetWatchedTwitterIds gets the IDs of the Twitter accounts watched by any live channel
+func (p *PostgresDatabase) GetWatchedTwitterIds(ctx context.Context) ([]string, error) {
+	var twitterIds []string
+	live := p.db.WithContext(ctx).Model(&models.Channel{}).Scopes(liveChannels).Select("id")
+	err := p.db.WithContext(ctx).Model(&models.ChannelWatch{}).Distinct("twitter_id").
+		Where("channel_id IN (?)", live).Order("twitter_id").Pluck("twitter_id", &twitterIds).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to query watched Twitter IDs: %v", err)
+	}
+	return twitterIds, nil
+}
+
+// DeleteChannel marks a channel deleted and notifies its followers in one transaction,
+// returning sql.ErrNoRows if there is no live channel with that ID
+func (p *PostgresDatabase) DeleteChannel(ctx context.Context, channelID string, deletedAt int64) error {
+	return p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Channel{}).Scopes(liveChannels).Where("id = ?", channelID).
+			UpdateColumn("deleted_at", deletedAt)
+		if result.Error != nil {
+			return fmt.Errorf("failed to delete channel: %v", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return sql.ErrNoRows
+		}
+		return notifyFollowers(tx, channelID, models.NotificationChannelDeleted, deletedAt)
+	})
+}
+
+// GetDeletedChannel gets a deleted channel by its ID, returning sql.ErrNoRows if there is no
+// such channel or it was not deleted
+func (p *PostgresDatabase) GetDeletedChannel(ctx context.Context, channelID string) (*models.Channel, error) {
+	var channel models.Channel
+	err := p.db.WithContext(ctx).Where("id = ? AND deleted_at IS NOT NULL", channelID).Take(&channel).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, sql.ErrNoRows
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query channel: %v", err)
+	}
+	emptyChannelLists(&channel)
+	return &channel, nil
+}
+
+// RestoreChannel clears the deletion mark of a channel and notifies its followers in one
+// transaction, returning sql.ErrNoRows if the channel is not deleted
+func (p *PostgresDatabase) RestoreChannel(ctx context.Context, channelID string) error {
+	return p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Channel{}).Where("id = ? AND deleted_at IS NOT NULL", channelID).
+			UpdateColumn("deleted_at", nil)
+		if result.Error != nil {
+			return fmt.Errorf("failed to restore channel: %v", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return sql.ErrNoRows
+		}
+		return notifyFollowers(tx, channelID, models.NotificationChannelRestored, time.Now().UnixMilli())
+	})
+}
+
+// PurgeDeletedChannels removes the channels deleted before deletedBefore together with their
+// follows, watchlist entries and versions, returning how many channels were removed
+func (p *PostgresDatabase) PurgeDeletedChannels(ctx context.Context, deletedBefore int64) (int, error) {
+	purged := 0
+	err := p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		expired := tx.Model(&models.Channel{}).Select("id").Where("deleted_at IS NOT NULL AND deleted_at < ?", deletedBefore)
+		if err := tx.Where("channel_id IN (?)", expired).Delete(&models.Follow{}).Error; err != nil {
+			return fmt.Errorf("failed to delete follows: %v", err)
+		}
+		if err := tx.Where("channel_id IN (?)", expired).Delete(&models.ChannelWatch{}).Error; err != nil {
+			return fmt.Errorf("failed to delete channel watches: %v", err)
+		}
+		if err := tx.Where("channel_id IN (?)", expired).Delete(&models.ChannelVersion{}).Error; err != nil {
+			return fmt.Errorf("failed to delete channel versions: %v", err)
+		}
+		result := tx.Where("deleted_at IS NOT NULL AND deleted_at < ?", deletedBefore).Delete(&models.Channel{})
+		if result.Error != nil {
+			return fmt.Errorf("failed to delete channels: %v", result.Error)
+		}
+		purged = int(result.RowsAffected)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return purged, nil
+}
+
+// notifyFollowers records a notification of the given type for every follower of a channel within tx
+func notifyFollowers(tx *gorm.DB, channelID, notificationType string, createdAt int64) error {
+	err := tx.Exec(`INSERT INTO notifications (user_id, type, channel_id, channel_name, created_at)
+		SELECT f.user_id, ?, c.id, c.name, ?
+		FROM follows f
+		JOIN channels c ON c.id = f.channel_id
+		WHERE f.channel_id = ?`, notificationType, createdAt, channelID).Error
+	if err != nil {
+		return fmt.Errorf("failed to notify followers: %v", err)
+	}
+	return nil
+}
+
+// GetNotifications gets the notifications of a user, newest first
+func (p *PostgresDatabase) GetNotifications(ctx context.Context, userID int, limit, offset int) ([]*models.Notification, error) {
+	var notifications []*models.Notification
+	err := p.db.WithContext(ctx).Where("user_id = ?", userID).
+		Order("created_at DESC, id DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&notifications).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to query notifications: %v", err)
+	}
+	return notifications, nil
+}
+
+// lockChannel locks a channel row for the rest of tx, returning sql.ErrNoRows if it does not exist
+// or was deleted
+func lockChannel(tx *gorm.DB, channelID string) error {
+	var channel models.Channel
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Scopes(liveChannels).Select("id").Where("id = ?", channelID).Take(&channel).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return sql.ErrNoRows
+	}
+	if err != nil {
+		return fmt.Errorf("failed to lock channel: %v", err)
+	}
+	return nil
+}
+
+// refreshRecentFollowers rewrites the recentFollowers of a channel from its limit most recent follows within tx
+func refreshRecentFollowers(tx *gorm.DB, channelID string, limit int) error {
+	followers := []int{}
+	err := tx.Model(&models.Follow{}).
+		Where("channel_id = ?", channelID).
+		Order("created_at DESC").
+		Limit(limit).
+		Pluck("user_id", &followers).Error
+	if err != nil {
+		return fmt.Errorf("failed to query recent followers: %v", err)
+	}
+
+	recentFollowers, err := json.Marshal(followers)
+	if err != nil {
+		return fmt.Errorf("failed to marshal recent followers: %v", err)
+	}
+	err = tx.Model(&models.Channel{}).Where("id = ?", channelID).
+		UpdateColumn("recent_followers", gorm.Expr("?::jsonb", string(recentFollowers))).Error
+	if err != nil {
+		return fmt.Errorf("failed to update recent followers: %v", err)
+	}
+	return nil
+}
+
+// FollowChannel creates a follow relationship between a user and a channel and updates the
+// channel's follower count in one transaction, returning false when the user already follows it
+func (p *PostgresDatabase) FollowChannel(ctx context.Context, follow *models.Follow) (bool, error) {
+	follow.CreatedAt = time.Now().UnixMilli()
+
+	created := false
+	err := p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := lockChannel(tx, follow.ChannelID); err != nil {
+			return err
+		}
+
+		row := *follow
+		result := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}, {Name: "channel_id"}},
+			DoNothing: true,
+		}).Create(&row)
+		if result.Error != nil {
+			return fmt.Errorf("failed to follow channel: %v", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		err := tx.Model(&models.Channel{}).Where("id = ?", follow.ChannelID).
+			UpdateColumn("follower_count", gorm.Expr("follower_count + 1")).Error
+		if err != nil {
+			return fmt.Errorf("failed to update follower count: %v", err)
+		}
+		created = true
+		return refreshRecentFollowers(tx, follow.ChannelID, p.recentFollowers)
+	})
+	if err != nil {
+		return false, err
+	}
+	return created, nil
+}
+
+// UnfollowChannel removes a follow relationship between a user and a channel and updates the
+// channel's follower count in one transaction, returning false when the user does not follow it
+func (p *PostgresDatabase) UnfollowChannel(ctx context.Context, userID int, channelID string) (bool, error) {
+	removed := false
+	err := p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := lockChannel(tx, channelID); err != nil {
+			return err
+		}
+
+		result := tx.Where("user_id = ? AND channel_id = ?", userID, channelID).Delete(&models.Follow{})
+		if result.Error != nil {
+			return fmt.Errorf("failed to unfollow channel: %v", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		err := tx.Model(&models.Channel{}).Where("id = ?", channelID).
+			UpdateColumn("follower_count", gorm.Expr("GREATEST(follower_count - 1, 0)")).Error
+		if err != nil {
+			return fmt.Errorf("failed to update follower count: %v", err)
+		}
+		removed = true
+		return refreshRecentFollowers(tx, channelID, p.recentFollowers)
+	})
+	if err != nil {
+		return false, err
+	}
+	return removed, nil
+}
+
+// CountFollowers counts the follows of a channel
+func (p *PostgresDatabase) CountFollowers(ctx context.Context, channelID string) (int, error) {
+	var count int64
+	if err := p.db.WithContext(ctx).Model(&models.Follow{}).Where("channel_id = ?", channelID).Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("failed to count followers: %v", err)
+	}
+	return int(count), nil
+}
+
+// CountChannels counts the channels that have not been deleted
+func (p *PostgresDatabase) CountChannels(ctx context.Context) (int, error) {
+	var count int64
+	if err := p.db.WithContext(ctx).Model(&models.Channel{}).Scopes(liveChannels).Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("failed to count channels: %v", err)
+	}
+	return int(count), nil
+}
+
+// CountFollows counts the follows of channels that have not been deleted
+func (p *PostgresDatabase) CountFollows(ctx context.Context) (int, error) {
+	var count int64
+	live := p.db.WithContext(ctx).Model(&models.Channel{}).Scopes(liveChannels).Select("id")
+	if err := p.db.WithContext(ctx).Model(&models.Follow{}).Where("channel_id IN (?)", live).Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("failed to count follows: %v", err)
+	}
+	return int(count), nil
+}
+
+// RepairFollowerStats recomputes the followerCount and recentFollowers of a channel from its
+// follows in one transaction
+func (p *PostgresDatabase) RepairFollowerStats(ctx context.Context, channelID string) error {
+	return p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := lockChannel(tx, channelID); err != nil {
+			return err
+		}
+
+		count := tx.Model(&models.Follow{}).Select("COUNT(*)").Where("channel_id = ?", channelID)
+		err := tx.Model(&models.Channel{}).Where("id = ?", channelID).UpdateColumn("follower_count", count).Error
+		if err != nil {
+			return fmt.Errorf("failed to update follower count: %v", err)
+		}
+		return refreshRecentFollowers(tx, channelID, p.recentFollowers)
+	})
+}
+
+// IsFollowing checks if a user is following a channel
+func (p *PostgresDatabase) IsFollowing(ctx context.Context, userID int, channelID string) (bool, error) {
+	var count int64
+	err := p.db.WithContext(ctx).Model(&models.Follow{}).Where("user_id = ? AND channel_id = ?", userID, channelID).Count(&count).Error
+	if err != nil {
+		return false, fmt.Errorf("failed to check follow status: %v", err)
+	}
+	return count > 0, nil
+}
+
+// GetFollowedChannels gets all channels followed by a user, skipping deleted channels
+func (p *PostgresDatabase) GetFollowedChannels(ctx context.Context, userID int) ([]*models.Follow, error) {
+	var follows []*models.Follow
+	live := p.db.WithContext(ctx).Model(&models.Channel{}).Scopes(liveChannels).Select("id")
+	if err := p.db.WithContext(ctx).Where("user_id = ? AND channel_id IN (?)", userID, live).Order("id").Find(&follows).Error; err != nil {
+		return nil, fmt.Errorf("failed to query follows: %v", err)
+	}
+	return follows, nil
+}
+
+// GetRecentFollowers gets the most recent followers for a channel
+func (p *PostgresDatabase) GetRecentFollowers(ctx context.Context, channelID string, limit int) ([]int, error) {
+	if limit <= 0 {
+		limit = 100 // Default to 100 if not specified
+	}
+
+	var followers []int
+	err := p.db.WithContext(ctx).Model(&models.Follow{}).
+		Where("channel_id = ?", channelID).
+		Order("created_at DESC").
+		Limit(limit).
+		Pluck("user_id", &followers).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to query recent followers: %v", err)
+	}
+	return followers, nil
+}
+
+// InsertTwitterInfo inserts a Twitter info record or updates it if the tweet was already ingested,
+// replacing the contract addresses recorded for it
+func (p *PostgresDatabase) InsertTwitterInfo(ctx context.Context, info *models.TwitterInfo) error {
+	if info.CreateTime == 0 {
+		info.CreateTime = time.Now().UnixMilli()
+	}
+
+	return p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		row := *info
+		row.ID = 0
+		err := tx.Omit("id", "deleted_at").Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "tweets_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"twitter_id", "content", "chain_id", "address", "cashtags", "type"}),
+		}).Create(&row).Error
+		if err != nil {
+			return fmt.Errorf("failed to insert Twitter info: %v", err)
+		}
+
+		if err := tx.Where("tweets_id = ?", info.TweetsId).Delete(&twitterInfoAddress{}).Error; err != nil {
+			return fmt.Errorf("failed to clear Twitter info addresses: %v", err)
+		}
+		if len(info.Addresses) == 0 {
+			return nil
+		}
+		addresses := make([]twitterInfoAddress, len(info.Addresses))
+		for i, addr := range info.Addresses {
+			addresses[i] = twitterInfoAddress{TweetsId: info.TweetsId, ChainId: addr.ChainId, Address: addr.Address, Position: i}
+		}
+		if err := tx.Create(&addresses).Error; err != nil {
+			return fmt.Errorf("failed to insert Twitter info address: %v", err)
+		}
+		return nil
+	})
+}
+
+// MarkTwitterInfoDeleted records that tweets were deleted by their author
+func (p *PostgresDatabase) MarkTwitterInfoDeleted(ctx context.Context, tweetsIds []string, deletedAt int64) error {
+	if len(tweetsIds) == 0 {
+		return nil
+	}
+	err := p.db.WithContext(ctx).Model(&models.TwitterInfo{}).
+		Where("deleted_at IS NULL AND tweets_id IN ?", tweetsIds).
+		UpdateColumn("deleted_at", deletedAt).Error
+	if err != nil {
+		return fmt.Errorf("failed to mark Twitter info deleted: %v", err)
+	}
+	return nil
+}
+
+// GetTwitterInfo gets the page of Twitter info selected by a content query
+func (p *PostgresDatabase) GetTwitterInfo(ctx context.Context, query ContentQuery) ([]*models.TwitterInfo, error) {
+	where, args := query.where(postgresContentColumns)
+
+	var twitterInfos []*models.TwitterInfo
+	err := p.db.WithContext(ctx).Where(where, args...).
+		Order("create_time DESC").
+		Limit(query.Limit).
+		Offset(query.Offset).
+		Find(&twitterInfos).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to query Twitter info: %v", err)
+	}
+
+	if err := p.loadTwitterInfoAddresses(ctx, twitterInfos); err != nil {
+		return nil, err
+	}
+	return twitterInfos, nil
+}
+
+// loadTwitterInfoAddresses fills the Addresses of each record from twitter_info_address
+func (p *PostgresDatabase) loadTwitterInfoAddresses(ctx context.Context, twitterInfos []*models.TwitterInfo) error {
+	if len(twitterInfos) == 0 {
+		return nil
+	}
+
+	byTweet := make(map[string]*models.TwitterInfo, len(twitterInfos))
+	tweetsIds := make([]string, 0, len(twitterInfos))
+	for _, info := range twitterInfos {
+		if _, ok := byTweet[info.TweetsId]; ok {
+			continue
+		}
+		byTweet[info.TweetsId] = info
+		tweetsIds = append(tweetsIds, info.TweetsId)
+	}
+
+	var addresses []twitterInfoAddress
+	if err := p.db.WithContext(ctx).Where("tweets_id IN ?", tweetsIds).Order("position").Find(&addresses).Error; err != nil {
+		return fmt.Errorf("failed to query Twitter info addresses: %v", err)
+	}
+	for _, addr := range addresses {
+		info := byTweet[addr.TweetsId]
+		info.Addresses = append(info.Addresses, models.ContractAddress{ChainId: addr.ChainId, Address: addr.Address})
+	}
+	return nil
+}
+
+// GetProfileUpdates gets the most recent profile update records of a Twitter account, oldest first
+func (p *PostgresDatabase) GetProfileUpdates(ctx context.Context, twitterId string, limit int) ([]*models.TwitterInfo, error) {
+	var updates []*models.TwitterInfo
+	err := p.db.WithContext(ctx).Where("twitter_id = ? AND type = ?", twitterId, models.TwitterInfoTypeUpdate).
+		Order("create_time DESC").
+		Limit(limit).
+		Find(&updates).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to query profile updates: %v", err)
+	}
+	for i, j := 0, len(updates)-1; i < j; i, j = i+1, j-1 {
+		updates[i], updates[j] = updates[j], updates[i]
+	}
+	return updates, nil
+}
+
+// GetTweetStats counts the stored tweets of a Twitter account, those mentioning a CA and those deleted
+func (p *PostgresDatabase) GetTweetStats(ctx context.Context, twitterId string) (*models.TweetStats, error) {
+	var stats models.TweetStats
+	err := p.db.WithContext(ctx).Raw(`
+		SELECT COUNT(*),
+		       COUNT(*) FILTER (WHERE COALESCE(address, '') <> ''),
+		       COUNT(*) FILTER (WHERE deleted_at IS NOT NULL)
+		FROM twitter_info
+		WHERE twitter_id = ? AND type = ?
+	`, twitterId, models.TwitterInfoTypeTweet).Row().Scan(&stats.Total, &stats.WithCA, &stats.Deleted)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count tweets: %v", err)
+	}
+
+	// Records ingested before twitter_info_address existed only carry their primary address
+	err = p.db.WithContext(ctx).Raw(`
+		SELECT COUNT(DISTINCT lower(address)) FROM (
+			SELECT address FROM twitter_info
+			WHERE twitter_id = ? AND type = ? AND COALESCE(address, '') <> ''
+			UNION
+			SELECT a.address FROM twitter_info_address a
+			JOIN twitter_info t ON t.tweets_id = a.tweets_id
+			WHERE t.twitter_id = ? AND t.type = ?
+		) cas
+	`, twitterId, models.TwitterInfoTypeTweet, twitterId, models.TwitterInfoTypeTweet).Row().Scan(&stats.DistinctCA)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count distinct CAs: %v", err)
+	}
+
+	return &stats, nil
+}
+
+// RecordTwitterHandle records that a Twitter account was seen with a username
+func (p *PostgresDatabase) RecordTwitterHandle(ctx context.Context, twitterId, userName string, seenAt int64) error {
+	handle := models.TwitterHandle{TwitterId: twitterId, UserName: userName, FirstSeenAt: seenAt, LastSeenAt: seenAt}
+	err := p.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "twitter_id"}, {Name: "user_name"}},
+		DoUpdates: clause.AssignmentColumns([]string{"last_seen_at"}),
+	}).Create(&handle).Error
+	if err != nil {
+		return fmt.Errorf("failed to record Twitter handle: %v", err)
+	}
+	return nil
+}
+
+// GetTwitterHandles gets the usernames a Twitter account has been seen with, most recent first
+func (p *PostgresDatabase) GetTwitterHandles(ctx context.Context, twitterId string) ([]*models.TwitterHandle, error) {
+	var handles []*models.TwitterHandle
+	if err := p.db.WithContext(ctx).Where("twitter_id = ?", twitterId).Order("last_seen_at DESC").Find(&handles).Error; err != nil {
+		return nil, fmt.Errorf("failed to query Twitter handles: %v", err)
+	}
+	return handles, nil
+}
+
+// UpsertTwitterAccount inserts or refreshes the cached metadata of a Twitter account
+func (p *PostgresDatabase) UpsertTwitterAccount(ctx context.Context, account *models.TwitterAccount) error {
+	row := *account
+	err := p.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "twitter_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"user_name", "display_name", "avatar", "verified", "followers_count", "following_count", "refreshed_at",
+		}),
+	}).Create(&row).Error
+	if err != nil {
+		return fmt.Errorf("failed to upsert Twitter account: %v", err)
+	}
+	return nil
+}
+
+// GetTwitterAccounts gets the cached metadata of Twitter accounts, keyed by Twitter ID
+func (p *PostgresDatabase) GetTwitterAccounts(ctx context.Context, twitterIds []string) (map[string]*models.TwitterAccount, error) {
+	accounts := make(map[string]*models.TwitterAccount)
+	if len(twitterIds) == 0 {
+		return accounts, nil
+	}
+
+	var rows []*models.TwitterAccount
+	if err := p.db.WithContext(ctx).Where("twitter_id IN ?", twitterIds).Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to query Twitter accounts: %v", err)
+	}
+	for _, account := range rows {
+		accounts[account.TwitterId] = account
+	}
+	return accounts, nil
+}
+
+// SaveRiskScore inserts or replaces the risk score of a Twitter account
+func (p *PostgresDatabase) SaveRiskScore(ctx context.Context, score *models.RiskScore) error {
+	row := *score
+	err := p.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "twitter_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"score", "level", "factors", "updated_at"}),
+	}).Create(&row).Error
+	if err != nil {
+		return fmt.Errorf("failed to save risk score: %v", err)
+	}
+	return nil
+}
+
+// GetRiskScores gets the stored risk scores of Twitter accounts, keyed by Twitter ID
+func (p *PostgresDatabase) GetRiskScores(ctx context.Context, twitterIds []string) (map[string]*models.RiskScore, error) {
+	scores := make(map[string]*models.RiskScore)
+	if len(twitterIds) == 0 {
+		return scores, nil
+	}
+
+	var rows []*models.RiskScore
+	if err := p.db.WithContext(ctx).Where("twitter_id IN ?", twitterIds).Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to query risk scores: %v", err)
+	}
+	for _, score := range rows {
+		scores[score.TwitterId] = score
+	}
+	return scores, nil
+}
@@ -0,0 +1,165 @@
+package database
+
+import (
+	"TwitterMonitor/internal/models"
+	"strings"
+)
+
+// AccountCriteria selects the content of one watched account: all of it, only the records
+// mentioning any CA, or only the records mentioning one of CAs
+type AccountCriteria struct {
+	TwitterId string
+	Mode      models.CAFilterMode
+	CAs       []string
+}
+
+// ContentQuery selects a page of the twitter_info records of watched accounts, newest first.
+// It is plain data: stores turn it into SQL in which every criteria value is a bound parameter
+type ContentQuery struct {
+	ContentType int
+	Accounts    []AccountCriteria
+	Limit       int
+	Offset      int
+}
+
+// NewContentQuery builds the query for the content of a watchlist, applying each entry's CA filter
+func NewContentQuery(watchlist []models.Watchlist, contentType, limit, offset int) ContentQuery {
+	query := ContentQuery{ContentType: contentType, Limit: limit, Offset: offset}
+	for _, watch := range watchlist {
+		mode, cas := watch.CAFilter()
+		query.Accounts = append(query.Accounts, AccountCriteria{TwitterId: watch.TwitterId, Mode: mode, CAs: cas})
+	}
+	return query
+}
+
+// contentColumns names the twitter_info columns of a schema. With foldCase, addresses are
+// compared in lower case instead of relying on the column collation
+type contentColumns struct {
+	twitterId string
+	address   string
+	tweetsId  string
+	foldCase  bool
+}
+
+var (
+	sqlContentColumns      = contentColumns{twitterId: "twitterId", address: "address", tweetsId: "tweetsId"}
+	postgresContentColumns = contentColumns{twitterId: "twitter_id", address: "address", tweetsId: "tweets_id", foldCase: true}
+)
+
+// sqlBuilder accumulates a SQL fragment and the arguments bound to its ? placeholders
+type sqlBuilder struct {
+	sql  strings.Builder
+	args []interface{}
+}
+
+// write appends trusted SQL text; values must go through bind or bindList
+func (b *sqlBuilder) write(fragment string) {
+	b.sql.WriteString(fragment)
+}
+
+// bind appends a placeholder bound to value
+func (b *sqlBuilder) bind(value interface{}) {
+	b.sql.WriteString("?")
+	b.args = append(b.args, value)
+}
+
+// bindList appends a comma-separated placeholder list for an IN clause. An empty list is
+// written as NULL, which matches nothing, rather than the invalid IN ()
+func (b *sqlBuilder) bindList(values []string) {
+	if len(values) == 0 {
+		b.write("NULL")
+		return
+	}
+	for i, value := range values {
+		if i > 0 {
+			b.write(", ")
+		}
+		b.bind(value)
+	}
+}
+
+// where builds the condition selecting the records of the query, without pagination
+func (q ContentQuery) where(columns contentColumns) (string, []interface{}) {
+	var b sqlBuilder
+	b.write("type = ")
+	b.bind(q.ContentType)
+	b.write(" AND (")
+	if len(q.Accounts) == 0 {
+		b.write("1 = 0")
+	}
+	for i, account := range q.Accounts {
+		if i > 0 {
+			b.write(" OR ")
+		}
+		account.condition(&b, columns)
+	}
+	b.write(")")
+	return b.sql.String(), b.args
+}
+
+// condition appends the condition selecting the records of one watched account
+func (a AccountCriteria) condition(b *sqlBuilder, columns contentColumns) {
+	b.write("(" + columns.twitterId + " = ")
+	b.bind(a.TwitterId)
+
+	switch a.Mode {
+	case models.CAFilterAny:
+		b.write(" AND COALESCE(" + columns.address + ", '') <> ''")
+	case models.CAFilterList:
+		// Match the primary address as well as any other CA recorded for the tweet
+		address, otherAddress, cas := columns.address, "a.address", a.CAs
+		if columns.foldCase {
+			address, otherAddress = "lower("+address+")", "lower(a.address)"
+			cas = make([]string, len(a.CAs))
+			for i, ca := range a.CAs {
+				cas[i] = strings.ToLower(ca)
+			}
+		}
+		b.write(" AND (" + address + " IN (")
+		b.bindList(cas)
+		b.write(") OR EXISTS (SELECT 1 FROM twitter_info_address a WHERE a." + columns.tweetsId +
+			" = twitter_info." + columns.tweetsId + " AND " + otherAddress + " IN (")
+		b.bindList(cas)
+		b.write(")))")
+	}
+	b.write(")")
+}
+
+// matches reports whether a record is selected by the query, mirroring the SQL built by where
+func (q ContentQuery) matches(info *models.TwitterInfo) bool {
+	if info.Type != q.ContentType {
+		return false
+	}
+	for _, account := range q.Accounts {
+		if account.matches(info) {
+			return true
+		}
+	}
+	return false
+}
+
+// matches reports whether a record belongs to the watched account and passes its CA filter
+func (a AccountCriteria) matches(info *models.TwitterInfo) bool {
+	if info.TwitterId != a.TwitterId {
+		return false
+	}
+
+	switch a.Mode {
+	case models.CAFilterAny:
+		return info.Address != ""
+	case models.CAFilterList:
+		for _, ca := range a.CAs {
+			if strings.EqualFold(info.Address, ca) {
+				return true
+			}
+			for _, addr := range info.Addresses {
+				if strings.EqualFold(addr.Address, ca) {
+					return true
+				}
+			}
+		}
+		return false
+	default:
+		return true
+	}
+}
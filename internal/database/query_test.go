@@ -0,0 +1,153 @@
+package database
+
+import (
+	"TwitterMonitor/internal/models"
+	"context"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// hostileValues are watchlist values that would change the meaning of a query built by
+// string concatenation
+var hostileValues = []string{
+	`' OR '1'='1`,
+	`1' OR '1'='1' --`,
+	`x'); DROP TABLE twitter_info; --`,
+	`;DROP TABLE twitter_info`,
+	`--`,
+	`\' OR 1=1 #`,
+	"nul\x00byte",
+	"%",
+	"_",
+	"abc%",
+	"ab_",
+	"ｔｏｋｅｎ🚀",
+	"ca‮exe",
+}
+
+// hostileWatch watches an account whose ID and CAs are value
+func hostileWatch(value string) models.Watchlist {
+	return models.Watchlist{TwitterId: value, CA: value, CAs: []string{value + "2"}}
+}
+
+func TestContentQueryBindsValues(t *testing.T) {
+	for _, columns := range []contentColumns{sqlContentColumns, postgresContentColumns} {
+		benign, _ := NewContentQuery([]models.Watchlist{hostileWatch("benign")}, models.TwitterInfoTypeTweet, 10, 0).where(columns)
+
+		for _, value := range hostileValues {
+			query := NewContentQuery([]models.Watchlist{hostileWatch(value)}, models.TwitterInfoTypeTweet, 10, 0)
+			sql, args := query.where(columns)
+
+			// Whatever the value, the SQL is the same and only holds placeholders
+			if sql != benign {
+				t.Errorf("where for %q = %s, want %s", value, sql, benign)
+			}
+			if placeholders := strings.Count(sql, "?"); placeholders != len(args) {
+				t.Errorf("where for %q has %d placeholders for %d args", value, placeholders, len(args))
+			}
+
+			cas := []interface{}{value, value + "2"}
+			if columns.foldCase {
+				cas = []interface{}{strings.ToLower(value), strings.ToLower(value + "2")}
+			}
+			want := append([]interface{}{models.TwitterInfoTypeTweet, value}, cas...)
+			want = append(want, cas...)
+			if !reflect.DeepEqual(args, want) {
+				t.Errorf("args for %q = %q, want %q", value, args, want)
+			}
+		}
+	}
+}
+
+func TestContentQueryEmptyWatchlist(t *testing.T) {
+	sql, args := NewContentQuery(nil, models.TwitterInfoTypeTweet, 10, 0).where(sqlContentColumns)
+	if sql != "type = ? AND (1 = 0)" || len(args) != 1 {
+		t.Errorf("where for no accounts = %s %v", sql, args)
+	}
+}
+
+// testStores returns the stores that run without a server: an empty memory store and a
+// migrated SQLite database
+func testStores(t *testing.T) map[string]Store {
+	t.Helper()
+	sqlite, err := NewSQLiteDatabase(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteDatabase: %v", err)
+	}
+	t.Cleanup(func() { sqlite.Close() })
+	migrator, err := sqlite.Migrator()
+	if err != nil {
+		t.Fatalf("Migrator: %v", err)
+	}
+	if _, err := migrator.Up(); err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+	return map[string]Store{"memory": NewMemoryDatabase(), "sqlite": sqlite}
+}
+
+// tweetsIds returns the IDs of the records selected by a content query, sorted by the store
+func tweetsIds(t *testing.T, store Store, watchlist ...models.Watchlist) []string {
+	t.Helper()
+	infos, err := store.GetTwitterInfo(context.Background(), NewContentQuery(watchlist, models.TwitterInfoTypeTweet, 100, 0))
+	if err != nil {
+		t.Fatalf("GetTwitterInfo: %v", err)
+	}
+	ids := []string{}
+	for _, info := range infos {
+		ids = append(ids, info.TweetsId)
+	}
+	return ids
+}
+
+func TestContentQueryHostileValues(t *testing.T) {
+	for name, store := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			// One legitimate account with a plain address, and one account per hostile value
+			// whose tweet mentions the value as its address
+			records := []*models.TwitterInfo{{TweetsId: "legit", TwitterId: "100", Address: "abc", Content: "buy abc"}}
+			for i, value := range hostileValues {
+				records = append(records, &models.TwitterInfo{TweetsId: fmt.Sprintf("hostile%d", i), TwitterId: value, Address: value})
+			}
+			for i, info := range records {
+				info.Type = models.TwitterInfoTypeTweet
+				info.CreateTime = int64(1000 + i)
+				info.Addresses = []models.ContractAddress{{ChainId: "ethereum", Address: info.Address}}
+				if err := store.InsertTwitterInfo(ctx, info); err != nil {
+					t.Fatalf("InsertTwitterInfo %q: %v", info.TwitterId, err)
+				}
+			}
+
+			for i, value := range hostileValues {
+				want := []string{fmt.Sprintf("hostile%d", i)}
+				if got := tweetsIds(t, store, models.Watchlist{TwitterId: value}); !reflect.DeepEqual(got, want) {
+					t.Errorf("account %q selected %v, want %v", value, got, want)
+				}
+				if got := tweetsIds(t, store, hostileWatch(value)); !reflect.DeepEqual(got, want) {
+					t.Errorf("account %q filtered by its own CA selected %v, want %v", value, got, want)
+				}
+
+				// The value is neither a wildcard nor a way out of the account's condition
+				if got := tweetsIds(t, store, models.Watchlist{TwitterId: "100", CA: value}); len(got) != 0 {
+					t.Errorf("legit account filtered by CA %q selected %v, want nothing", value, got)
+				}
+				anyCA := models.Watchlist{TwitterId: "100", FilterCA: models.CAFilterList, CAs: []string{value}}
+				if got := tweetsIds(t, store, anyCA, models.Watchlist{TwitterId: value + "x"}); len(got) != 0 {
+					t.Errorf("CA list %q selected %v, want nothing", value, got)
+				}
+			}
+
+			// The tables survived and the legitimate account sees only its own record
+			if got := tweetsIds(t, store, models.Watchlist{TwitterId: "100", CA: "abc"}); !reflect.DeepEqual(got, []string{"legit"}) {
+				t.Errorf("legit account selected %v, want [legit]", got)
+			}
+			if got := tweetsIds(t, store, models.Watchlist{TwitterId: "100", FilterCA: models.CAFilterAny}); !reflect.DeepEqual(got, []string{"legit"}) {
+				t.Errorf("legit account with any CA selected %v, want [legit]", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package database
+
+import (
+	"TwitterMonitor/internal/models"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// GetProfileUpdates gets the most recent profile update records of a Twitter account, oldest first
+func (db *Database) GetProfileUpdates(ctx context.Context, twitterId string, limit int) ([]*models.TwitterInfo, error) {
+	query := `
+		SELECT id, tweetsId, twitterId, content, COALESCE(chainId, ''), COALESCE(address, ''), COALESCE(cashtags, ''), createTime, type
+		FROM (
+			SELECT id, tweetsId, twitterId, content, chainId, address, cashtags, createTime, type FROM twitter_info
+			WHERE twitterId = ? AND type = ?
+			ORDER BY createTime DESC
+			LIMIT ?
+		) recent
+		ORDER BY createTime ASC
+	`
+
+	rows, err := db.db.QueryContext(ctx, query, twitterId, models.TwitterInfoTypeUpdate, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query profile updates: %v", err)
+	}
+	defer rows.Close()
+
+	var twitterInfos []*models.TwitterInfo
+	for rows.Next() {
+		var info models.TwitterInfo
+		if err := rows.Scan(&info.ID, &info.TweetsId, &info.TwitterId, &info.Content, &info.ChainId, &info.Address, &info.Cashtags, &info.CreateTime, &info.Type); err != nil {
+			return nil, fmt.Errorf("failed to scan profile update: %v", err)
+		}
+		twitterInfos = append(twitterInfos, &info)
+	}
+
+	return twitterInfos, rows.Err()
+}
+
+// GetTweetStats counts the stored tweets of a Twitter account, those mentioning a CA and those deleted
+func (db *Database) GetTweetStats(ctx context.Context, twitterId string) (*models.TweetStats, error) {
+	var stats models.TweetStats
+	query := `
+		SELECT COUNT(*),
+		       COALESCE(SUM(CASE WHEN COALESCE(address, '') <> '' THEN 1 ELSE 0 END), 0),
+		       COALESCE(SUM(CASE WHEN deletedAt IS NOT NULL THEN 1 ELSE 0 END), 0)
+		FROM twitter_info
+		WHERE twitterId = ? AND type = ?
+	`
+	err := db.db.QueryRowContext(ctx, query, twitterId, models.TwitterInfoTypeTweet).Scan(&stats.Total, &stats.WithCA, &stats.Deleted)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count tweets: %v", err)
+	}
+
+	// Records ingested before twitter_info_address existed only carry their primary address
+	query = `
+		SELECT COUNT(DISTINCT address) FROM (
+			SELECT address FROM twitter_info
+			WHERE twitterId = ? AND type = ? AND COALESCE(address, '') <> ''
+			UNION
+			SELECT a.address FROM twitter_info_address a
+			JOIN twitter_info t ON t.tweetsId = a.tweetsId
+			WHERE t.twitterId = ? AND t.type = ?
+		) cas
+	`
+	err = db.db.QueryRowContext(ctx, query, twitterId, models.TwitterInfoTypeTweet, twitterId, models.TwitterInfoTypeTweet).Scan(&stats.DistinctCA)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count distinct CAs: %v", err)
+	}
+
+	return &stats, nil
+}
+
+// SaveRiskScore inserts or replaces the risk score of a Twitter account
+func (db *Database) SaveRiskScore(ctx context.Context, score *models.RiskScore) error {
+	factorsJSON, err := json.Marshal(score.Factors)
+	if err != nil {
+		return fmt.Errorf("failed to marshal risk factors: %v", err)
+	}
+
+	query := `INSERT INTO account_risk (twitterId, score, level, factors, updatedAt)
+	          VALUES (?, ?, ?, ?, ?) ` +
+		db.dialect.upsert([]string{"twitterId"}, []string{"score", "level", "factors", "updatedAt"})
+	_, err = db.db.ExecContext(ctx, query, score.TwitterId, score.Score, score.Level, string(factorsJSON), score.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save risk score: %v", err)
+	}
+	return nil
+}
+
+// GetRiskScores gets the stored risk scores of Twitter accounts, keyed by Twitter ID
+func (db *Database) GetRiskScores(ctx context.Context, twitterIds []string) (map[string]*models.RiskScore, error) {
+	scores := make(map[string]*models.RiskScore)
+	if len(twitterIds) == 0 {
+		return scores, nil
+	}
+
+	placeholders := make([]string, len(twitterIds))
+	args := make([]interface{}, len(twitterIds))
+	for i, id := range twitterIds {
+		placeholders[i] = "?"
+		args[i] = id
+	}
+
+	query := "SELECT twitterId, score, level, factors, updatedAt FROM account_risk WHERE twitterId IN (" + strings.Join(placeholders, ",") + ")"
+	rows, err := db.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query risk scores: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var score models.RiskScore
+		var factorsStr sql.NullString
+		if err := rows.Scan(&score.TwitterId, &score.Score, &score.Level, &factorsStr, &score.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan risk score: %v", err)
+		}
+		if factorsStr.Valid && factorsStr.String != "" {
+			if err := json.Unmarshal([]byte(factorsStr.String), &score.Factors); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal risk factors: %v", err)
+			}
+		}
+		scores[score.TwitterId] = &score
+	}
+
+	return scores, rows.Err()
+}
@@ -0,0 +1,36 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	_ "modernc.org/sqlite"
+)
+
+// NewSQLiteDatabase opens the SQLite database file at path. It is meant for local development
+// and tests, where a MySQL server is not available
+func NewSQLiteDatabase(path string) (*Database, error) {
+	dsn := path
+	if !strings.Contains(dsn, "_pragma=") {
+		separator := "?"
+		if strings.Contains(dsn, "?") {
+			separator = "&"
+		}
+		dsn += separator + "_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_pragma=foreign_keys(1)"
+	}
+
+	db, err := sql.Open(sqliteDialect.driver, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open SQLite database: %v", err)
+	}
+	// SQLite allows a single writer; one connection avoids SQLITE_BUSY between our own transactions
+	db.SetMaxOpenConns(1)
+
+	// Check the connection
+	if err := db.Ping(); err != nil {
+		return nil, fmt.Errorf("failed to ping SQLite database: %v", err)
+	}
+
+	return &Database{db: db, dialect: sqliteDialect, recentFollowers: DefaultRecentFollowersLimit}, nil
+}
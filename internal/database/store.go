@@ -0,0 +1,174 @@
+package database
+
+import (
+	"TwitterMonitor/internal/models"
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// ChannelStore persists channels. Deletion is soft: DeleteChannel sets deletedAt and notifies the
+// followers, reads other than GetDeletedChannel skip deleted channels, and PurgeDeletedChannels
+// removes channels deleted before a cutoff together with their follows
+type ChannelStore interface {
+	InsertOrUpdateChannel(ctx context.Context, channel *models.Channel, authorID int) error
+	GetChannelsByOwnerID(ctx context.Context, ownerID int) ([]*models.Channel, error)
+	GetChannelsByID(ctx context.Context, id string) ([]*models.Channel, error)
+	GetChannelByID(ctx context.Context, channelID string) (*models.Channel, error)
+	GetChannelByIDs(ctx context.Context, channelIDs []string) ([]*models.Channel, error)
+	GetAllChannels(ctx context.Context, limit, offset int) ([]*models.Channel, error)
+	GetChannelsWatching(ctx context.Context, twitterId string) ([]*models.Channel, error)
+	GetChannelWatches(ctx context.Context, twitterId string) ([]*models.ChannelWatch, error)
+	GetWatchedTwitterIds(ctx context.Context) ([]string, error)
+	DeleteChannel(ctx context.Context, channelID string, deletedAt int64) error
+	GetDeletedChannel(ctx context.Context, channelID string) (*models.Channel, error)
+	RestoreChannel(ctx context.Context, channelID string) error
+	PurgeDeletedChannels(ctx context.Context, deletedBefore int64) (int, error)
+}
+
+// DefaultRecentFollowersLimit is how many of the most recent followers a channel keeps in
+// recentFollowers unless Options sets another limit
+const DefaultRecentFollowersLimit = 50
+
+// FollowStore persists follow relationships and the follower counts derived from them.
+// FollowChannel and UnfollowChannel are idempotent and report whether they changed anything
+type FollowStore interface {
+	FollowChannel(ctx context.Context, follow *models.Follow) (bool, error)
+	UnfollowChannel(ctx context.Context, userID int, channelID string) (bool, error)
+	IsFollowing(ctx context.Context, userID int, channelID string) (bool, error)
+	GetFollowedChannels(ctx context.Context, userID int) ([]*models.Follow, error)
+	GetRecentFollowers(ctx context.Context, channelID string, limit int) ([]int, error)
+	CountFollowers(ctx context.Context, channelID string) (int, error)
+	RepairFollowerStats(ctx context.Context, channelID string) error
+}
+
+// ChannelVersionStore reads the configuration history of channels. InsertOrUpdateChannel records
+// a new version, attributed to its author, whenever it changes the versioned configuration
+type ChannelVersionStore interface {
+	GetChannelVersions(ctx context.Context, channelID string, limit, offset int) ([]*models.ChannelVersion, error)
+	GetChannelVersion(ctx context.Context, channelID string, version int) (*models.ChannelVersion, error)
+}
+
+// NotificationStore reads the notifications sent to users
+type NotificationStore interface {
+	GetNotifications(ctx context.Context, userID int, limit, offset int) ([]*models.Notification, error)
+}
+
+// TwitterInfoStore persists ingested tweets and profile updates
+type TwitterInfoStore interface {
+	InsertTwitterInfo(ctx context.Context, info *models.TwitterInfo) error
+	MarkTwitterInfoDeleted(ctx context.Context, tweetsIds []string, deletedAt int64) error
+	GetTwitterInfo(ctx context.Context, query ContentQuery) ([]*models.TwitterInfo, error)
+	GetProfileUpdates(ctx context.Context, twitterId string, limit int) ([]*models.TwitterInfo, error)
+	GetTweetStats(ctx context.Context, twitterId string) (*models.TweetStats, error)
+}
+
+// AccountStore persists watched-account metadata, handle history and risk scores
+type AccountStore interface {
+	RecordTwitterHandle(ctx context.Context, twitterId, userName string, seenAt int64) error
+	GetTwitterHandles(ctx context.Context, twitterId string) ([]*models.TwitterHandle, error)
+	UpsertTwitterAccount(ctx context.Context, account *models.TwitterAccount) error
+	GetTwitterAccounts(ctx context.Context, twitterIds []string) (map[string]*models.TwitterAccount, error)
+	SaveRiskScore(ctx context.Context, score *models.RiskScore) error
+	GetRiskScores(ctx context.Context, twitterIds []string) (map[string]*models.RiskScore, error)
+}
+
+// StatsStore reports the totals exported as business metrics
+type StatsStore interface {
+	CountChannels(ctx context.Context) (int, error)
+	CountFollows(ctx context.Context) (int, error)
+}
+
+// Store is the persistence layer used by handlers and background jobs
+type Store interface {
+	ChannelStore
+	ChannelVersionStore
+	FollowStore
+	NotificationStore
+	TwitterInfoStore
+	AccountStore
+	StatsStore
+
+	// Ping checks that the store is reachable
+	Ping(ctx context.Context) error
+	// Close releases the connections of the store
+	Close() error
+}
+
+var (
+	_ Store = (*Database)(nil)
+	_ Store = (*MemoryDatabase)(nil)
+	_ Store = (*PostgresDatabase)(nil)
+
+	_ Migratable = (*Database)(nil)
+	_ Migratable = (*PostgresDatabase)(nil)
+
+	_ Pooled = (*Database)(nil)
+	_ Pooled = (*PostgresDatabase)(nil)
+)
+
+// Pooled is implemented by stores backed by a sql.DB connection pool
+type Pooled interface {
+	PoolStats() sql.DBStats
+}
+
+// Wrapper is implemented by stores decorating another store, like the metrics decorator
+type Wrapper interface {
+	Unwrap() Store
+}
+
+// Unwrap returns the store at the bottom of a chain of decorators
+func Unwrap(store Store) Store {
+	for {
+		wrapper, ok := store.(Wrapper)
+		if !ok {
+			return store
+		}
+		store = wrapper.Unwrap()
+	}
+}
+
+// Options tunes a store opened by Open; zero values keep the defaults
+type Options struct {
+	RecentFollowersLimit int
+}
+
+// Open opens the store named by a database URL: memory:// selects the in-memory store,
+// sqlite://<path> a SQLite file, postgres:// or postgresql:// a PostgreSQL database, and
+// anything else is treated as a MySQL DSN, optionally prefixed with mysql://
+func Open(uri string, opts Options) (Store, error) {
+	recentFollowers := DefaultRecentFollowersLimit
+	if opts.RecentFollowersLimit > 0 {
+		recentFollowers = opts.RecentFollowersLimit
+	}
+
+	if strings.HasPrefix(uri, "memory://") {
+		db := NewMemoryDatabase()
+		db.recentFollowers = recentFollowers
+		return db, nil
+	}
+	if strings.HasPrefix(uri, "postgres://") || strings.HasPrefix(uri, "postgresql://") {
+		db, err := NewPostgresDatabase(uri)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open database: %v", err)
+		}
+		db.recentFollowers = recentFollowers
+		return db, nil
+	}
+
+	var (
+		db  *Database
+		err error
+	)
+	if path, ok := strings.CutPrefix(uri, "sqlite://"); ok {
+		db, err = NewSQLiteDatabase(path)
+	} else {
+		db, err = NewDatabase(strings.TrimPrefix(uri, "mysql://"))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %v", err)
+	}
+	db.recentFollowers = recentFollowers
+	return db, nil
+}
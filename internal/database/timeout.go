@@ -0,0 +1,68 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Timeouts bounds each store operation; a zero duration leaves operations bounded only by the
+// caller's context
+type Timeouts struct {
+	// Read bounds queries
+	Read time.Duration
+	// Write bounds operations that modify the store
+	Write time.Duration
+}
+
+// WithTimeouts cancels every operation of store that outlives the timeout returned by timeouts,
+// which is called per operation so that the timeouts can change while the server runs.
+// An operation ended by its deadline or by the caller's context fails with an error matching
+// context.DeadlineExceeded or context.Canceled
+func WithTimeouts(store Store, timeouts func() Timeouts) Store {
+	return WithHook(store, func(ctx context.Context, op string, write bool, call func(context.Context) error) (err error) {
+		limits := timeouts()
+		timeout := limits.Read
+		if write {
+			timeout = limits.Write
+		}
+		ctx, cancel := withTimeout(ctx, timeout)
+		defer done(ctx, cancel, &err)
+		return call(ctx)
+	})
+}
+
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// done releases the context of an operation. The stores format rather than wrap driver errors,
+// so the failure of an operation whose context ended is marked with the cause here
+func done(ctx context.Context, cancel context.CancelFunc, err *error) {
+	if *err != nil && ctx.Err() != nil && !errors.Is(*err, ctx.Err()) {
+		*err = &interruptedError{err: *err, cause: ctx.Err()}
+	}
+	cancel()
+}
+
+// interruptedError is the failure of an operation whose context ended
+type interruptedError struct {
+	err   error
+	cause error
+}
+
+func (e *interruptedError) Error() string {
+	if strings.Contains(e.err.Error(), e.cause.Error()) {
+		return e.err.Error()
+	}
+	return fmt.Sprintf("%v: %v", e.err, e.cause)
+}
+
+func (e *interruptedError) Unwrap() []error {
+	return []error{e.err, e.cause}
+}
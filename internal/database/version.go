@@ -0,0 +1,131 @@
+package database
+
+import (
+	"TwitterMonitor/internal/models"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+)
+
+// channelVersionColumns is the projection of the channel_versions table read by scanChannelVersion
+const channelVersionColumns = "channelId, version, authorId, createdAt, name, description, isPublic, watchlist, eventlist"
+
+// scanChannelVersion scans a row selected with channelVersionColumns
+func scanChannelVersion(row rowScanner) (*models.ChannelVersion, error) {
+	var version models.ChannelVersion
+	var description sql.NullString
+	var watchlist, eventlist []byte
+	err := row.Scan(
+		&version.ChannelID,
+		&version.Version,
+		&version.AuthorID,
+		&version.CreatedAt,
+		&version.Name,
+		&description,
+		&version.IsPublic,
+		&watchlist,
+		&eventlist,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	version.Description = description.String
+	if err := unmarshalJSONColumn(watchlist, &version.Watchlist); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal watchlist: %v", err)
+	}
+	if err := unmarshalJSONColumn(eventlist, &version.Eventlist); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal eventlist: %v", err)
+	}
+	emptyVersionLists(&version)
+	return &version, nil
+}
+
+// emptyVersionLists replaces missing watchlist and eventlist values with empty lists
+func emptyVersionLists(version *models.ChannelVersion) {
+	if version.Watchlist == nil {
+		version.Watchlist = []models.Watchlist{}
+	}
+	if version.Eventlist == nil {
+		version.Eventlist = []models.EventList{}
+	}
+}
+
+// recordChannelVersion stores version as the next version of its channel within tx, unless it
+// does not change the configuration of the latest version
+func (db *Database) recordChannelVersion(ctx context.Context, tx *sql.Tx, version *models.ChannelVersion) error {
+	row := tx.QueryRowContext(ctx, "SELECT "+channelVersionColumns+" FROM channel_versions WHERE channelId = ? ORDER BY version DESC LIMIT 1", version.ChannelID)
+	latest, err := scanChannelVersion(row)
+	switch {
+	case err == sql.ErrNoRows:
+		version.Version = 1
+	case err != nil:
+		return fmt.Errorf("failed to query latest channel version: %v", err)
+	case models.DiffChannelVersions(latest, version).Empty():
+		return nil
+	default:
+		version.Version = latest.Version + 1
+	}
+
+	watchlistJSON, err := json.Marshal(version.Watchlist)
+	if err != nil {
+		return fmt.Errorf("failed to marshal watchlist: %v", err)
+	}
+	eventlistJSON, err := json.Marshal(version.Eventlist)
+	if err != nil {
+		return fmt.Errorf("failed to marshal eventlist: %v", err)
+	}
+
+	query := `INSERT INTO channel_versions (channelId, version, authorId, createdAt, name, description, isPublic, watchlist, eventlist)
+	          VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	_, err = tx.ExecContext(ctx, query,
+		version.ChannelID,
+		version.Version,
+		version.AuthorID,
+		version.CreatedAt,
+		version.Name,
+		version.Description,
+		version.IsPublic,
+		string(watchlistJSON),
+		string(eventlistJSON),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert channel version: %v", err)
+	}
+	return nil
+}
+
+// GetChannelVersions gets the versions of a channel, newest first
+func (db *Database) GetChannelVersions(ctx context.Context, channelID string, limit, offset int) ([]*models.ChannelVersion, error) {
+	query := "SELECT " + channelVersionColumns + " FROM channel_versions WHERE channelId = ? ORDER BY version DESC LIMIT ? OFFSET ?"
+	rows, err := db.db.QueryContext(ctx, query, channelID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query channel versions: %v", err)
+	}
+	defer rows.Close()
+
+	var versions []*models.ChannelVersion
+	for rows.Next() {
+		version, err := scanChannelVersion(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan channel version: %v", err)
+		}
+		versions = append(versions, version)
+	}
+
+	return versions, rows.Err()
+}
+
+// GetChannelVersion gets one version of a channel, returning sql.ErrNoRows if it does not exist
+func (db *Database) GetChannelVersion(ctx context.Context, channelID string, version int) (*models.ChannelVersion, error) {
+	row := db.db.QueryRowContext(ctx, "SELECT "+channelVersionColumns+" FROM channel_versions WHERE channelId = ? AND version = ?", channelID, version)
+	channelVersion, err := scanChannelVersion(row)
+	if err == sql.ErrNoRows {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan channel version: %v", err)
+	}
+	return channelVersion, nil
+}
@@ -0,0 +1,106 @@
+package database
+
+import (
+	"TwitterMonitor/internal/models"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+)
+
+// replaceChannelWatches rewrites the channel_watch rows of a channel from its watchlist within tx
+func (db *Database) replaceChannelWatches(ctx context.Context, tx *sql.Tx, channelID string, watchlist []models.Watchlist) error {
+	if _, err := tx.ExecContext(ctx, "DELETE FROM channel_watch WHERE channelId = ?", channelID); err != nil {
+		return fmt.Errorf("failed to clear channel watches: %v", err)
+	}
+
+	query := `INSERT INTO channel_watch (channelId, position, twitterId, tweets, profileUpdate, follows, filterCA, ca, cas)
+	          VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	for _, watch := range models.ChannelWatchesFromWatchlist(channelID, watchlist) {
+		casJSON, err := json.Marshal(watch.CAs)
+		if err != nil {
+			return fmt.Errorf("failed to marshal CAs: %v", err)
+		}
+		_, err = tx.ExecContext(ctx, query,
+			watch.ChannelID,
+			watch.Position,
+			watch.TwitterId,
+			watch.Tweets,
+			watch.ProfileUpdate,
+			watch.Follows,
+			string(watch.FilterCA),
+			watch.CA,
+			string(casJSON),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert channel watch: %v", err)
+		}
+	}
+	return nil
+}
+
+// GetChannelWatches gets the watchlist entries of all live channels watching a Twitter account
+func (db *Database) GetChannelWatches(ctx context.Context, twitterId string) ([]*models.ChannelWatch, error) {
+	query := `SELECT channelId, position, twitterId, tweets, profileUpdate, follows, COALESCE(filterCA, ''), COALESCE(ca, ''), cas
+	          FROM channel_watch
+	          WHERE twitterId = ? AND channelId IN (SELECT id FROM channels WHERE ` + liveChannel + `)
+	          ORDER BY channelId, position`
+	rows, err := db.db.QueryContext(ctx, query, twitterId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query channel watches: %v", err)
+	}
+	defer rows.Close()
+
+	var watches []*models.ChannelWatch
+	for rows.Next() {
+		var watch models.ChannelWatch
+		var casJSON []byte
+		err := rows.Scan(
+			&watch.ChannelID,
+			&watch.Position,
+			&watch.TwitterId,
+			&watch.Tweets,
+			&watch.ProfileUpdate,
+			&watch.Follows,
+			&watch.FilterCA,
+			&watch.CA,
+			&casJSON,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan channel watch: %v", err)
+		}
+		if err := unmarshalJSONColumn(casJSON, &watch.CAs); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal CAs: %v", err)
+		}
+		watches = append(watches, &watch)
+	}
+
+	return watches, rows.Err()
+}
+
+// GetChannelsWatching gets the channels whose watchlist contains a Twitter account
+func (db *Database) GetChannelsWatching(ctx context.Context, twitterId string) ([]*models.Channel, error) {
+	query := "SELECT " + channelColumns + " FROM channels WHERE id IN (SELECT channelId FROM channel_watch WHERE twitterId = ?) AND " + liveChannel + " ORDER BY id"
+	return db.queryChannels(ctx, query, twitterId)
+}
+
+// GetWatchedTwitterIds gets the IDs of the Twitter accounts watched by any live channel
+func (db *Database) GetWatchedTwitterIds(ctx context.Context) ([]string, error) {
+	query := "SELECT DISTINCT twitterId FROM channel_watch WHERE channelId IN (SELECT id FROM channels WHERE " + liveChannel + ") ORDER BY twitterId"
+	rows, err := db.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query watched Twitter IDs: %v", err)
+	}
+	defer rows.Close()
+
+	var twitterIds []string
+	for rows.Next() {
+		var twitterId string
+		if err := rows.Scan(&twitterId); err != nil {
+			return nil, fmt.Errorf("failed to scan watched Twitter ID: %v", err)
+		}
+		twitterIds = append(twitterIds, twitterId)
+	}
+
+	return twitterIds, rows.Err()
+}
@@ -0,0 +1,156 @@
+package database
+
+import (
+	"TwitterMonitor/internal/models"
+	"context"
+	"database/sql"
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+// watchedAccounts are the Twitter IDs the test channels may watch
+var watchedAccounts = []string{"1", "2", "3"}
+
+// testChannel is a channel of owner watching the given entries
+func testChannel(id string, owner int, watchlist ...models.Watchlist) *models.Channel {
+	return &models.Channel{ID: id, OwnerID: owner, Name: id, Watchlist: watchlist, Eventlist: []models.EventList{}}
+}
+
+// checkWatches compares the reverse index of a store with the watchlists of live, the
+// channels that should not be deleted
+func checkWatches(t *testing.T, store Store, step string, live ...*models.Channel) {
+	t.Helper()
+	ctx := context.Background()
+
+	wantIds := []string{}
+	wantChannels := make(map[string][]string)
+	wantWatches := make(map[string][]*models.ChannelWatch)
+	for _, channel := range live {
+		stored, err := store.GetChannelByID(ctx, channel.ID)
+		if err != nil {
+			t.Fatalf("%s: GetChannelByID %s: %v", step, channel.ID, err)
+		}
+		if !reflect.DeepEqual(stored.Watchlist, channel.Watchlist) {
+			t.Errorf("%s: watchlist of %s = %+v, want %+v", step, channel.ID, stored.Watchlist, channel.Watchlist)
+		}
+
+		for _, watch := range models.ChannelWatchesFromWatchlist(channel.ID, channel.Watchlist) {
+			if len(wantChannels[watch.TwitterId]) == 0 {
+				wantIds = append(wantIds, watch.TwitterId)
+			}
+			if channels := wantChannels[watch.TwitterId]; len(channels) == 0 || channels[len(channels)-1] != channel.ID {
+				wantChannels[watch.TwitterId] = append(channels, channel.ID)
+			}
+			wantWatches[watch.TwitterId] = append(wantWatches[watch.TwitterId], &watch)
+		}
+	}
+	sort.Strings(wantIds)
+
+	twitterIds, err := store.GetWatchedTwitterIds(ctx)
+	if err != nil {
+		t.Fatalf("%s: GetWatchedTwitterIds: %v", step, err)
+	}
+	if len(twitterIds) != 0 || len(wantIds) != 0 {
+		if !reflect.DeepEqual(twitterIds, wantIds) {
+			t.Errorf("%s: watched accounts = %v, want %v", step, twitterIds, wantIds)
+		}
+	}
+
+	for _, twitterId := range watchedAccounts {
+		channels, err := store.GetChannelsWatching(ctx, twitterId)
+		if err != nil {
+			t.Fatalf("%s: GetChannelsWatching %s: %v", step, twitterId, err)
+		}
+		var channelIds []string
+		for _, channel := range channels {
+			channelIds = append(channelIds, channel.ID)
+		}
+		if !reflect.DeepEqual(channelIds, wantChannels[twitterId]) {
+			t.Errorf("%s: channels watching %s = %v, want %v", step, twitterId, channelIds, wantChannels[twitterId])
+		}
+
+		watches, err := store.GetChannelWatches(ctx, twitterId)
+		if err != nil {
+			t.Fatalf("%s: GetChannelWatches %s: %v", step, twitterId, err)
+		}
+		if !reflect.DeepEqual(watches, wantWatches[twitterId]) {
+			t.Errorf("%s: watches of %s = %+v, want %+v", step, twitterId, watches, wantWatches[twitterId])
+		}
+	}
+}
+
+// countWatchRows returns the number of channel_watch rows of a SQL store, or -1 for the memory store
+func countWatchRows(t *testing.T, store Store) int {
+	t.Helper()
+	db, ok := store.(*Database)
+	if !ok {
+		return -1
+	}
+	var count int
+	if err := db.db.QueryRow("SELECT COUNT(*) FROM channel_watch").Scan(&count); err != nil {
+		t.Fatalf("count channel_watch rows: %v", err)
+	}
+	return count
+}
+
+func TestChannelWatchesFollowWatchlist(t *testing.T) {
+	for name, store := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			first := testChannel("c1", 1,
+				models.Watchlist{TwitterId: "1", TwitterName: "one", Tweets: true},
+				models.Watchlist{TwitterId: "2", TwitterName: "two", Follows: true, FilterCA: models.CAFilterList, CAs: []string{"0xabc"}},
+				// Unresolved entries are kept in the watchlist but not indexed
+				models.Watchlist{TwitterName: "pending"},
+			)
+			second := testChannel("c2", 2, models.Watchlist{TwitterId: "2", TwitterName: "two", ProfileUpdate: true})
+			for _, channel := range []*models.Channel{first, second} {
+				if err := store.InsertOrUpdateChannel(ctx, channel, channel.OwnerID); err != nil {
+					t.Fatalf("create %s: %v", channel.ID, err)
+				}
+			}
+			checkWatches(t, store, "create", first, second)
+
+			// Reordering, dropping and adding entries rewrites the rows of the channel
+			first.Watchlist = []models.Watchlist{
+				{TwitterId: "3", TwitterName: "three", Tweets: true, CA: "0xdef"},
+				{TwitterId: "1", TwitterName: "one", ProfileUpdate: true},
+			}
+			if err := store.InsertOrUpdateChannel(ctx, first, first.OwnerID); err != nil {
+				t.Fatalf("update: %v", err)
+			}
+			checkWatches(t, store, "update", first, second)
+
+			if err := store.DeleteChannel(ctx, second.ID, time.Now().UnixMilli()); err != nil {
+				t.Fatalf("delete: %v", err)
+			}
+			checkWatches(t, store, "delete", first)
+			if err := store.DeleteChannel(ctx, second.ID, time.Now().UnixMilli()); !errors.Is(err, sql.ErrNoRows) {
+				t.Errorf("delete deleted channel = %v, want sql.ErrNoRows", err)
+			}
+
+			if err := store.RestoreChannel(ctx, second.ID); err != nil {
+				t.Fatalf("restore: %v", err)
+			}
+			checkWatches(t, store, "restore", first, second)
+
+			if err := store.DeleteChannel(ctx, first.ID, time.Now().UnixMilli()-1); err != nil {
+				t.Fatalf("delete before purge: %v", err)
+			}
+			if purged, err := store.PurgeDeletedChannels(ctx, time.Now().UnixMilli()); err != nil || purged != 1 {
+				t.Fatalf("PurgeDeletedChannels = %d, %v; want 1", purged, err)
+			}
+			checkWatches(t, store, "purge", second)
+			if rows := countWatchRows(t, store); rows != -1 && rows != len(second.Watchlist) {
+				t.Errorf("purge left %d channel_watch rows, want %d", rows, len(second.Watchlist))
+			}
+			if err := store.RestoreChannel(ctx, first.ID); !errors.Is(err, sql.ErrNoRows) {
+				t.Errorf("restore purged channel = %v, want sql.ErrNoRows", err)
+			}
+		})
+	}
+}
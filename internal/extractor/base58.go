@@ -0,0 +1,45 @@
+package extractor
+
+import "math/big"
+
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+var base58Index = func() [256]int {
+	var index [256]int
+	for i := range index {
+		index[i] = -1
+	}
+	for i := 0; i < len(base58Alphabet); i++ {
+		index[base58Alphabet[i]] = i
+	}
+	return index
+}()
+
+// decodeBase58 decodes a bitcoin-alphabet base58 string, returning false on invalid input
+func decodeBase58(s string) ([]byte, bool) {
+	if s == "" {
+		return nil, false
+	}
+
+	num := new(big.Int)
+	radix := big.NewInt(58)
+	for i := 0; i < len(s); i++ {
+		v := base58Index[s[i]]
+		if v < 0 {
+			return nil, false
+		}
+		num.Mul(num, radix)
+		num.Add(num, big.NewInt(int64(v)))
+	}
+
+	// Leading '1's encode leading zero bytes
+	zeros := 0
+	for zeros < len(s) && s[zeros] == '1' {
+		zeros++
+	}
+
+	decoded := num.Bytes()
+	out := make([]byte, zeros+len(decoded))
+	copy(out[zeros:], decoded)
+	return out, true
+}
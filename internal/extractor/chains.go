@@ -0,0 +1,179 @@
+package extractor
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"strings"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// Chain identifiers, matching the chain slugs used by dexscreener
+const (
+	ChainEthereum = "ethereum"
+	ChainBSC      = "bsc"
+	ChainBase     = "base"
+	ChainArbitrum = "arbitrum"
+	ChainPolygon  = "polygon"
+	ChainSolana   = "solana"
+	ChainTon      = "ton"
+	ChainTron     = "tron"
+)
+
+// evmChainKeywords maps words commonly used in tweets to the EVM chain they refer to
+var evmChainKeywords = map[string]string{
+	"eth":       ChainEthereum,
+	"ethereum":  ChainEthereum,
+	"erc20":     ChainEthereum,
+	"bsc":       ChainBSC,
+	"bnb":       ChainBSC,
+	"bep20":     ChainBSC,
+	"binance":   ChainBSC,
+	"basechain": ChainBase,
+	"arb":       ChainArbitrum,
+	"arbitrum":  ChainArbitrum,
+	"polygon":   ChainPolygon,
+	"matic":     ChainPolygon,
+}
+
+// isEVMChain reports whether a chain slug belongs to the EVM address family
+func isEVMChain(chain string) bool {
+	switch chain {
+	case ChainSolana, ChainTon, ChainTron:
+		return false
+	}
+	return chain != ""
+}
+
+// inferEVMChain guesses the chain of EVM addresses in a tweet from the first chain keyword it mentions
+func inferEVMChain(text string) string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9')
+	})
+	for _, word := range words {
+		if chain, ok := evmChainKeywords[word]; ok {
+			return chain
+		}
+	}
+	return ChainEthereum
+}
+
+// validEVMAddress checks a 0x-prefixed 20-byte hex address, enforcing the EIP-55 checksum for mixed-case input
+func validEVMAddress(addr string) bool {
+	if len(addr) != 42 || !strings.HasPrefix(addr, "0x") {
+		return false
+	}
+	hexPart := addr[2:]
+	if strings.Trim(hexPart, "0") == "" {
+		return false
+	}
+
+	lower := strings.ToLower(hexPart)
+	if hexPart == lower || hexPart == strings.ToUpper(hexPart) {
+		return true
+	}
+
+	hash := sha3.NewLegacyKeccak256()
+	hash.Write([]byte(lower))
+	sum := hash.Sum(nil)
+	for i := 0; i < len(hexPart); i++ {
+		c := hexPart[i]
+		if c >= '0' && c <= '9' {
+			continue
+		}
+		nibble := sum[i/2]
+		if i%2 == 0 {
+			nibble >>= 4
+		} else {
+			nibble &= 0x0f
+		}
+		if (nibble >= 8) != (c >= 'A' && c <= 'F') {
+			return false
+		}
+	}
+	return true
+}
+
+// validSolanaAddress checks that a base58 string decodes to a 32-byte public key
+func validSolanaAddress(addr string) bool {
+	if len(addr) < 32 || len(addr) > 44 {
+		return false
+	}
+	decoded, ok := decodeBase58(addr)
+	return ok && len(decoded) == 32
+}
+
+// validTronAddress checks a base58check Tron address (version byte 0x41)
+func validTronAddress(addr string) bool {
+	if len(addr) != 34 || addr[0] != 'T' {
+		return false
+	}
+	decoded, ok := decodeBase58(addr)
+	if !ok || len(decoded) != 25 || decoded[0] != 0x41 {
+		return false
+	}
+	first := sha256.Sum256(decoded[:21])
+	second := sha256.Sum256(first[:])
+	return string(second[:4]) == string(decoded[21:])
+}
+
+// validTonAddress checks both the raw (workchain:hex) and user-friendly (base64 with CRC16) TON formats
+func validTonAddress(addr string) bool {
+	if i := strings.IndexByte(addr, ':'); i > 0 {
+		workchain, hexPart := addr[:i], addr[i+1:]
+		if workchain != "0" && workchain != "-1" || len(hexPart) != 64 {
+			return false
+		}
+		for j := 0; j < len(hexPart); j++ {
+			if !isHexByte(hexPart[j]) {
+				return false
+			}
+		}
+		return true
+	}
+
+	if len(addr) != 48 {
+		return false
+	}
+	var decoded []byte
+	var err error
+	if strings.ContainsAny(addr, "-_") {
+		decoded, err = base64.URLEncoding.DecodeString(addr)
+	} else {
+		decoded, err = base64.StdEncoding.DecodeString(addr)
+	}
+	if err != nil || len(decoded) != 36 {
+		return false
+	}
+
+	// Tag: bounceable 0x11 or non-bounceable 0x51, optionally with the 0x80 testnet flag
+	tag := decoded[0] &^ 0x80
+	if tag != 0x11 && tag != 0x51 {
+		return false
+	}
+	if decoded[1] != 0x00 && decoded[1] != 0xff {
+		return false
+	}
+	crc := crc16XModem(decoded[:34])
+	return byte(crc>>8) == decoded[34] && byte(crc) == decoded[35]
+}
+
+// crc16XModem computes the CRC16 (XMODEM variant) used by TON user-friendly addresses
+func crc16XModem(data []byte) uint16 {
+	var crc uint16
+	for _, b := range data {
+		crc ^= uint16(b) << 8
+		for i := 0; i < 8; i++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
+
+func isHexByte(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F'
+}
@@ -0,0 +1,169 @@
+package extractor
+
+import (
+	"TwitterMonitor/internal/models"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var (
+	evmPattern         = regexp.MustCompile(`0x[0-9a-fA-F]{40,}`)
+	base58Pattern      = regexp.MustCompile(`[1-9A-HJ-NP-Za-km-z]{32,44}`)
+	tonRawPattern      = regexp.MustCompile(`-?[0-9]+:[0-9a-fA-F]{64}`)
+	tonFriendlyPattern = regexp.MustCompile(`[EUk0]Q[A-Za-z0-9_\-+/]{46}`)
+	cashtagPattern     = regexp.MustCompile(`\$[A-Za-z][A-Za-z0-9_]{0,14}`)
+	dexscreenerPattern = regexp.MustCompile(`(?i)dexscreener\.com/([a-z0-9]+)/([^\s/?#]+)`)
+)
+
+// Result holds everything extracted from the content of a tweet
+type Result struct {
+	Addresses []models.ContractAddress
+	Cashtags  []string
+}
+
+// match is a validated address candidate and where it appeared in the text
+type match struct {
+	pos     int
+	chainId string
+	address string
+}
+
+// Extract scans tweet content for contract addresses and cashtags.
+// Addresses are returned in order of appearance without duplicates
+func Extract(text string) Result {
+	linkChains := chainsFromLinks(text)
+	evmChain := inferEVMChain(text)
+
+	var matches []match
+	for _, loc := range findTokens(evmPattern, text, isAlnum) {
+		addr := text[loc[0]:loc[1]]
+		if !validEVMAddress(addr) {
+			continue
+		}
+		chain := evmChain
+		if linked, ok := linkChains[strings.ToLower(addr)]; ok && isEVMChain(linked) {
+			chain = linked
+		}
+		matches = append(matches, match{pos: loc[0], chainId: chain, address: addr})
+	}
+
+	for _, loc := range findTokens(base58Pattern, text, isAlnum) {
+		addr := text[loc[0]:loc[1]]
+		switch {
+		case validTronAddress(addr):
+			matches = append(matches, match{pos: loc[0], chainId: ChainTron, address: addr})
+		case validSolanaAddress(addr):
+			matches = append(matches, match{pos: loc[0], chainId: ChainSolana, address: addr})
+		}
+	}
+
+	for _, loc := range findTokens(tonRawPattern, text, isAlnum) {
+		addr := text[loc[0]:loc[1]]
+		if validTonAddress(addr) {
+			matches = append(matches, match{pos: loc[0], chainId: ChainTon, address: addr})
+		}
+	}
+
+	for _, loc := range findTokens(tonFriendlyPattern, text, isBase64Byte) {
+		addr := text[loc[0]:loc[1]]
+		if validTonAddress(addr) {
+			matches = append(matches, match{pos: loc[0], chainId: ChainTon, address: addr})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].pos < matches[j].pos
+	})
+
+	var result Result
+	seen := make(map[string]bool)
+	for _, m := range matches {
+		key := strings.ToLower(m.address)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		result.Addresses = append(result.Addresses, models.ContractAddress{
+			ChainId: m.chainId,
+			Address: m.address,
+		})
+	}
+
+	seenTags := make(map[string]bool)
+	for _, loc := range findTokens(cashtagPattern, text, isCashtagByte) {
+		tag := strings.ToUpper(text[loc[0]+1 : loc[1]])
+		if seenTags[tag] {
+			continue
+		}
+		seenTags[tag] = true
+		result.Cashtags = append(result.Cashtags, tag)
+	}
+
+	return result
+}
+
+// Apply fills the contract address fields of a Twitter info record from its content.
+// The first address found becomes the record's primary address/chainId unless one was already supplied
+func Apply(info *models.TwitterInfo) {
+	result := Extract(info.Content)
+
+	if info.Address != "" {
+		supplied := models.ContractAddress{ChainId: info.ChainId, Address: info.Address}
+		found := false
+		for _, addr := range result.Addresses {
+			if strings.EqualFold(addr.Address, info.Address) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			result.Addresses = append([]models.ContractAddress{supplied}, result.Addresses...)
+		}
+	} else if len(result.Addresses) > 0 {
+		info.Address = result.Addresses[0].Address
+		info.ChainId = result.Addresses[0].ChainId
+	}
+
+	info.Addresses = result.Addresses
+	info.Cashtags = strings.Join(result.Cashtags, ",")
+}
+
+// chainsFromLinks maps addresses found in dexscreener links (dexscreener.com/<chain>/<address>)
+// to the chain the link names. Mints in pump.fun links (pump.fun/coin/<mint>) need no hint since
+// the base58 scan already recognizes them as Solana addresses
+func chainsFromLinks(text string) map[string]string {
+	chains := make(map[string]string)
+	for _, m := range dexscreenerPattern.FindAllStringSubmatch(text, -1) {
+		chains[strings.ToLower(m[2])] = strings.ToLower(m[1])
+	}
+	return chains
+}
+
+// findTokens returns the locations of pattern matches that are not embedded in a longer word,
+// where isWordByte decides which neighbouring bytes would extend the token
+func findTokens(pattern *regexp.Regexp, text string, isWordByte func(byte) bool) [][]int {
+	var locs [][]int
+	for _, loc := range pattern.FindAllStringIndex(text, -1) {
+		if loc[0] > 0 && isWordByte(text[loc[0]-1]) {
+			continue
+		}
+		if loc[1] < len(text) && isWordByte(text[loc[1]]) {
+			continue
+		}
+		locs = append(locs, loc)
+	}
+	return locs
+}
+
+func isAlnum(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func isBase64Byte(c byte) bool {
+	return isAlnum(c) || c == '-' || c == '_'
+}
+
+func isCashtagByte(c byte) bool {
+	return isAlnum(c) || c == '_' || c == '$'
+}
@@ -0,0 +1,168 @@
+package extractor
+
+import (
+	"TwitterMonitor/internal/models"
+	"reflect"
+	"testing"
+)
+
+const (
+	evmAddress      = "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"
+	evmAddressBadCS = "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAeD"
+	usdtEVM         = "0xdAC17F958D2ee523a2206206994597C13D831ec7"
+	tronAddress     = "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t"
+	tronAddressBad  = "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6u"
+	solanaAddress   = "EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v"
+	wrappedSol      = "So11111111111111111111111111111111111111112"
+	tonFriendly     = "EQCxE6mUtQJKFnGfaROTKOt1lZbDiiX1kCixRv7Nw2Id_sDs"
+	tonFriendlyBad  = "EQCxE6mUtQJKFnGfaROTKOt1lZbDiiX1kCixRv7Nw2Id_sDt"
+	tonRaw          = "0:b113a994b5024a16719f69139328eb759596c38a25f59028b146fecdc3621dfe"
+	solanaSignature = "1XTRN2RJN5MfCYf2UTVo8dcmei94CvVB2BBWvvograKBRzTW7RaDH79KZ4NSHQNUNLf3DwSbhdsgmDYptsM2d21"
+)
+
+func TestValidators(t *testing.T) {
+	tests := []struct {
+		name  string
+		valid func(string) bool
+		addr  string
+		want  bool
+	}{
+		{"evm checksummed", validEVMAddress, evmAddress, true},
+		{"evm checksum one case flipped", validEVMAddress, evmAddressBadCS, false},
+		{"evm all lower case", validEVMAddress, "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed", true},
+		{"evm all upper case", validEVMAddress, "0x5AAEB6053F3E94C9B9A09F33669435E7EF1BEAED", true},
+		{"evm zero address", validEVMAddress, "0x0000000000000000000000000000000000000000", false},
+		{"evm too long", validEVMAddress, evmAddress + "0", false},
+		{"tron", validTronAddress, tronAddress, true},
+		{"tron last char changed", validTronAddress, tronAddressBad, false},
+		{"tron without T prefix", validTronAddress, "A" + tronAddress[1:], false},
+		{"solana mint", validSolanaAddress, solanaAddress, true},
+		{"solana wrapped sol", validSolanaAddress, wrappedSol, true},
+		{"solana 31 bytes", validSolanaAddress, wrappedSol[:len(wrappedSol)-1], false},
+		{"solana invalid base58 char", validSolanaAddress, "0" + solanaAddress[1:], false},
+		{"solana transaction signature", validSolanaAddress, solanaSignature, false},
+		{"ton friendly", validTonAddress, tonFriendly, true},
+		{"ton friendly crc changed", validTonAddress, tonFriendlyBad, false},
+		{"ton raw", validTonAddress, tonRaw, true},
+		{"ton raw unknown workchain", validTonAddress, "5" + tonRaw[1:], false},
+		{"ton raw short", validTonAddress, tonRaw[:len(tonRaw)-1], false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.valid(tt.addr); got != tt.want {
+				t.Errorf("valid(%q) = %v, want %v", tt.addr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtract(t *testing.T) {
+	tests := []struct {
+		name      string
+		text      string
+		addresses []models.ContractAddress
+		cashtags  []string
+	}{
+		{
+			name:      "evm defaults to ethereum",
+			text:      "CA: " + evmAddress,
+			addresses: []models.ContractAddress{{ChainId: ChainEthereum, Address: evmAddress}},
+		},
+		{
+			name: "evm with bad checksum",
+			text: "CA: " + evmAddressBadCS,
+		},
+		{
+			name:      "evm chain from keyword",
+			text:      "new $PEPE on BSC: " + evmAddress + "!",
+			addresses: []models.ContractAddress{{ChainId: ChainBSC, Address: evmAddress}},
+			cashtags:  []string{"PEPE"},
+		},
+		{
+			name:      "dexscreener link overrides keyword",
+			text:      "eth gem https://dexscreener.com/base/" + evmAddress + "?ref=x",
+			addresses: []models.ContractAddress{{ChainId: ChainBase, Address: evmAddress}},
+		},
+		{
+			name: "dexscreener link naming a non-EVM chain is ignored for EVM addresses",
+			text: "https://dexscreener.com/solana/" + evmAddress,
+			addresses: []models.ContractAddress{
+				{ChainId: ChainEthereum, Address: evmAddress},
+			},
+		},
+		{
+			name:      "solana in pump.fun link",
+			text:      "https://pump.fun/coin/" + solanaAddress,
+			addresses: []models.ContractAddress{{ChainId: ChainSolana, Address: solanaAddress}},
+		},
+		{
+			name: "solana signature is not an address",
+			text: "tx https://solscan.io/tx/" + solanaSignature,
+		},
+		{
+			name: "address embedded in a longer word",
+			text: "x" + solanaAddress + "x",
+		},
+		{
+			name: "tron, ton and solana in punctuation",
+			text: "(" + tronAddress + "), [" + tonFriendly + "]; \"" + tonRaw + "\" and " + wrappedSol + ".",
+			addresses: []models.ContractAddress{
+				{ChainId: ChainTron, Address: tronAddress},
+				{ChainId: ChainTon, Address: tonFriendly},
+				{ChainId: ChainTon, Address: tonRaw},
+				{ChainId: ChainSolana, Address: wrappedSol},
+			},
+		},
+		{
+			name: "corrupted tron and ton",
+			text: tronAddressBad + " " + tonFriendlyBad,
+		},
+		{
+			name: "duplicates keep first-seen order",
+			text: usdtEVM + " " + solanaAddress + " " + "0xdac17f958d2ee523a2206206994597c13d831ec7 $sol $SOL " + solanaAddress,
+			addresses: []models.ContractAddress{
+				{ChainId: ChainEthereum, Address: usdtEVM},
+				{ChainId: ChainSolana, Address: solanaAddress},
+			},
+			cashtags: []string{"SOL"},
+		},
+		{
+			name:     "dollar amounts are not cashtags",
+			text:     "$100 or $$BONK or $WIF_2",
+			cashtags: []string{"WIF_2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Extract(tt.text)
+			if !reflect.DeepEqual(got.Addresses, tt.addresses) {
+				t.Errorf("addresses = %v, want %v", got.Addresses, tt.addresses)
+			}
+			if !reflect.DeepEqual(got.Cashtags, tt.cashtags) {
+				t.Errorf("cashtags = %v, want %v", got.Cashtags, tt.cashtags)
+			}
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	info := &models.TwitterInfo{Content: "buy " + solanaAddress + " and " + evmAddress}
+	Apply(info)
+	if info.Address != solanaAddress || info.ChainId != ChainSolana {
+		t.Errorf("primary address = %s/%s, want %s/%s", info.ChainId, info.Address, ChainSolana, solanaAddress)
+	}
+	if len(info.Addresses) != 2 {
+		t.Errorf("addresses = %v, want 2", info.Addresses)
+	}
+
+	// A supplied address stays primary and is kept when the content does not mention it
+	info = &models.TwitterInfo{Content: "buy " + solanaAddress, ChainId: ChainTron, Address: tronAddress}
+	Apply(info)
+	want := []models.ContractAddress{
+		{ChainId: ChainTron, Address: tronAddress},
+		{ChainId: ChainSolana, Address: solanaAddress},
+	}
+	if info.Address != tronAddress || !reflect.DeepEqual(info.Addresses, want) {
+		t.Errorf("Apply kept %s with %v, want %s with %v", info.Address, info.Addresses, tronAddress, want)
+	}
+}
@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"TwitterMonitor/config"
+	"TwitterMonitor/internal/models"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminHandler serves operational endpoints
+type AdminHandler struct {
+	cfg *config.Live
+}
+
+// NewAdminHandler creates a new admin handler
+func NewAdminHandler(cfg *config.Live) *AdminHandler {
+	return &AdminHandler{cfg: cfg}
+}
+
+// GetConfig shows the effective configuration with secrets redacted, which settings are
+// reloadable and which changes wait for a restart
+func (h *AdminHandler) GetConfig(c *gin.Context) {
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    h.cfg.Snapshot(),
+	})
+}
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"TwitterMonitor/config"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newAdminRouter serves /admin/config with the admin token given as a flag
+func newAdminRouter(t *testing.T, args ...string) *gin.Engine {
+	t.Helper()
+	t.Setenv("ADMIN_TOKEN", "")
+	live, _, err := config.LoadLive(append([]string{"--database-url", "memory://"}, args...))
+	if err != nil {
+		t.Fatalf("LoadLive: %v", err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/admin/config", AdminAuth(live), NewAdminHandler(live).GetConfig)
+	return router
+}
+
+func TestAdminAuth(t *testing.T) {
+	tests := []struct {
+		name          string
+		args          []string
+		authorization string
+		status        int
+	}{
+		{"disabled without a token", nil, "Bearer ", http.StatusForbidden},
+		{"missing token", []string{"--admin-token", "s3cret"}, "", http.StatusUnauthorized},
+		{"wrong token", []string{"--admin-token", "s3cret"}, "Bearer s3cre", http.StatusUnauthorized},
+		{"not a bearer token", []string{"--admin-token", "s3cret"}, "s3cret", http.StatusUnauthorized},
+		{"valid token", []string{"--admin-token", "s3cret"}, "Bearer s3cret", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := newAdminRouter(t, tt.args...)
+			req := httptest.NewRequest(http.MethodGet, "/admin/config", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.status, rec.Body.String())
+			}
+			if strings.Contains(rec.Body.String(), "s3cret") {
+				t.Errorf("response shows the admin token: %s", rec.Body.String())
+			}
+		})
+	}
+}
@@ -1,17 +1,29 @@
 package handlers
 
 import (
+	"TwitterMonitor/config"
 	"TwitterMonitor/internal/database"
+	"TwitterMonitor/internal/metrics"
 	"TwitterMonitor/internal/models"
-	"TwitterMonitor/internal/utils"
+	"TwitterMonitor/internal/risk"
+	"TwitterMonitor/internal/tracing"
+	"TwitterMonitor/internal/twitter"
+	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 var upgrader = websocket.Upgrader{
@@ -24,22 +36,32 @@ var upgrader = websocket.Upgrader{
 
 // ChannelHandler handles channel-related requests
 type ChannelHandler struct {
-	db *database.Database
+	db            database.Store
+	scorer        *risk.Scorer
+	twitterClient *twitter.Client
+	resolver      *twitter.Resolver
+	cfg           *config.Live
 }
 
-// NewChannelHandler creates a new channel handler
-func NewChannelHandler(db *database.Database) *ChannelHandler {
-	return &ChannelHandler{db: db}
+// NewChannelHandler creates a new channel handler using the channel limits, market API and
+// feature toggles of cfg, read on every request so that reloads apply; deleted channels can
+// be restored by their owner for cfg.Channels.RestoreDays
+func NewChannelHandler(db database.Store, twitterClient *twitter.Client, cfg *config.Live) *ChannelHandler {
+	return &ChannelHandler{
+		db:            db,
+		scorer:        risk.NewScorer(db),
+		twitterClient: twitterClient,
+		resolver:      twitter.NewResolver(twitterClient, db),
+		cfg:           cfg,
+	}
 }
 
 func (h *ChannelHandler) CreateChannel(c *gin.Context) {
+	res := respond(c, v1CodeMessage)
 	var req models.CreateOrUpdateChannelRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		utils.LogError("Error parsing request: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{
-			"code":    400,
-			"message": "Invalid request parameters",
-		})
+		slog.ErrorContext(c.Request.Context(), "Error parsing request", "error", err)
+		res.fail(http.StatusBadRequest, models.ErrCodeInvalidRequest, "Invalid request parameters")
 		return
 	}
 
@@ -48,22 +70,27 @@ func (h *ChannelHandler) CreateChannel(c *gin.Context) {
 		return
 	}
 
+	if !h.resolveWatchlist(c, req.Watchlist) {
+		return
+	}
+
+	// Risk fields are computed server-side, whatever the client sent
+	if err := h.scorer.Fill(c.Request.Context(), req.Watchlist); err != nil {
+		slog.ErrorContext(c.Request.Context(), "Error scoring watchlist", "error", err)
+		res.failErr(err, http.StatusInternalServerError, "Failed to score watchlist")
+		return
+	}
+
 	// Check if the user already has a channel
-	channels, err := h.db.GetChannelsByOwnerID(userID)
+	channels, err := h.db.GetChannelsByOwnerID(c.Request.Context(), userID)
 	if err != nil {
-		utils.LogError("Error getting channels: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"code":    500,
-			"message": "Failed to check existing channels",
-		})
+		slog.ErrorContext(c.Request.Context(), "Error getting channels", "error", err)
+		res.failErr(err, http.StatusInternalServerError, "Failed to check existing channels")
 		return
 	}
 
 	if len(channels) > 0 {
-		c.JSON(http.StatusForbidden, gin.H{
-			"code":    403,
-			"message": "User already has a channel",
-		})
+		res.fail(http.StatusForbidden, models.ErrCodeQuotaExceeded, "User already has a channel")
 		return
 	}
 
@@ -81,35 +108,27 @@ func (h *ChannelHandler) CreateChannel(c *gin.Context) {
 		HotExpireAt:     "",
 		Watchlist:       req.Watchlist,
 		Eventlist:       req.Eventlist,
-		FollowerCount:   "0",
+		FollowerCount:   0,
 		RecentFollowers: []int{},
 	}
 
-	if err := h.db.InsertOrUpdateChannel(channel); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"code":    500,
-			"message": "Failed to create channel" + err.Error(),
-		})
+	if err := h.db.InsertOrUpdateChannel(c.Request.Context(), channel, userID); err != nil {
+		slog.ErrorContext(c.Request.Context(), "Error creating channel", "error", err)
+		res.failErr(err, http.StatusInternalServerError, "Failed to create channel")
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"code":    10000,
-		"message": "success",
-		"data": gin.H{
-			"channel": channel,
-		},
+	res.ok(gin.H{
+		"channel": channel,
 	})
 }
 
 func (h *ChannelHandler) UpdateChannel(c *gin.Context) {
+	res := respond(c, v1CodeMessage)
 	var req models.CreateOrUpdateChannelRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		utils.LogError("Error parsing request: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{
-			"code":    400,
-			"message": "Invalid request parameters",
-		})
+		slog.ErrorContext(c.Request.Context(), "Error parsing request", "error", err)
+		res.fail(http.StatusBadRequest, models.ErrCodeInvalidRequest, "Invalid request parameters")
 		return
 	}
 
@@ -118,30 +137,32 @@ func (h *ChannelHandler) UpdateChannel(c *gin.Context) {
 		return
 	}
 
+	if !h.resolveWatchlist(c, req.Watchlist) {
+		return
+	}
+
+	// Risk fields are computed server-side, whatever the client sent
+	if err := h.scorer.Fill(c.Request.Context(), req.Watchlist); err != nil {
+		slog.ErrorContext(c.Request.Context(), "Error scoring watchlist", "error", err)
+		res.failErr(err, http.StatusInternalServerError, "Failed to score watchlist")
+		return
+	}
+
 	// Check if the user already has a channel
-	channels, err := h.db.GetChannelsByOwnerID(userID)
+	channels, err := h.db.GetChannelsByOwnerID(c.Request.Context(), userID)
 	if err != nil {
-		utils.LogError("Error getting channels: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"code":    500,
-			"message": "Failed to check existing channels",
-		})
+		slog.ErrorContext(c.Request.Context(), "Error getting channels", "error", err)
+		res.failErr(err, http.StatusInternalServerError, "Failed to check existing channels")
 		return
 	}
 
 	if len(channels) == 0 {
-		c.JSON(http.StatusForbidden, gin.H{
-			"code":    403,
-			"message": "User not has a channel",
-		})
+		res.fail(http.StatusForbidden, models.ErrCodeChannelNotFound, "User not has a channel")
 		return
 	}
 
 	if userID != channels[0].OwnerID {
-		c.JSON(http.StatusForbidden, gin.H{
-			"code":    403,
-			"message": "User not permitted to change channel",
-		})
+		res.fail(http.StatusForbidden, models.ErrCodeNotChannelOwner, "User not permitted to change channel")
 		return
 	}
 
@@ -171,61 +192,38 @@ func (h *ChannelHandler) UpdateChannel(c *gin.Context) {
 	existingChannel.IsPublic = req.IsPublic
 
 	// Update the channel
-	if err := h.db.InsertOrUpdateChannel(existingChannel); err != nil {
-		utils.LogError("Error updating channel: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"code":    500,
-			"message": "Failed to update channel",
-		})
+	if err := h.db.InsertOrUpdateChannel(c.Request.Context(), existingChannel, userID); err != nil {
+		slog.ErrorContext(c.Request.Context(), "Error updating channel", "error", err)
+		res.failErr(err, http.StatusInternalServerError, "Failed to update channel")
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"code":    10000,
-		"message": "success",
-		"data": gin.H{
-			"channel": existingChannel,
-		},
+	res.ok(gin.H{
+		"channel": existingChannel,
 	})
 
 }
 
 func (h *ChannelHandler) DeleteChannel(c *gin.Context) {
+	res := respond(c, v1CodeMessage)
 	var req models.DeleteChannelRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		utils.LogError("Error parsing request: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{
-			"code":    400,
-			"message": "Invalid request parameters",
-		})
+		slog.ErrorContext(c.Request.Context(), "Error parsing request", "error", err)
+		res.fail(http.StatusBadRequest, models.ErrCodeInvalidRequest, "Invalid request parameters")
 		return
 	}
 
 	// Check if userID is provided
 	if req.UserID == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"code":    400,
-			"message": "userID is required",
-		})
+		res.fail(http.StatusBadRequest, models.ErrCodeInvalidRequest, "userID is required")
 		return
 	}
 
 	// Check if the channel exists and belongs to the user
-	channels, err := h.db.GetChannelsByOwnerID(req.UserID)
+	channels, err := h.db.GetChannelsByOwnerID(c.Request.Context(), req.UserID)
 	if err != nil {
-		utils.LogError("Error getting channels: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"code":    500,
-			"message": "Failed to check channel ownership",
-		})
-		return
-	}
-
-	if req.UserID != channels[0].OwnerID {
-		c.JSON(http.StatusForbidden, gin.H{
-			"code":    403,
-			"message": "User not permitted to change channel",
-		})
+		slog.ErrorContext(c.Request.Context(), "Error getting channels", "error", err)
+		res.failErr(err, http.StatusInternalServerError, "Failed to check channel ownership")
 		return
 	}
 
@@ -238,87 +236,293 @@ func (h *ChannelHandler) DeleteChannel(c *gin.Context) {
 		}
 	}
 
+	notFound := func() {
+		message := "Channel not found or you don't have permission to delete it"
+		if !isV2(c) {
+			// /v1 clients expect code 403 with this 404
+			res.v1Error(http.StatusNotFound, http.StatusForbidden, message)
+			return
+		}
+		res.fail(http.StatusNotFound, models.ErrCodeChannelNotFound, message)
+	}
 	if channelToDelete == nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"code":    403,
-			"message": "Channel not found or you don't have permission to delete it",
-		})
+		notFound()
+		return
+	}
+
+	// Mark the channel deleted; it can be restored until the grace period ends
+	deletedAt := time.Now().UnixMilli()
+	err = h.db.DeleteChannel(c.Request.Context(), req.ID, deletedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		// Deleted by a concurrent request
+		notFound()
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Error deleting channel", "error", err)
+		res.failErr(err, http.StatusInternalServerError, "Failed to delete channel")
+		return
+	}
+	channelToDelete.DeletedAt = deletedAt
+
+	res.ok(gin.H{
+		"channel":       channelToDelete,
+		"restoreBefore": deletedAt + h.cfg.Get().Channels.RestoreGrace().Milliseconds(),
+	})
+}
+
+// RestoreChannel restores a deleted channel for its owner within the grace period
+func (h *ChannelHandler) RestoreChannel(c *gin.Context) {
+	res := respond(c, v1CodeMessage)
+	var req models.RestoreChannelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		slog.ErrorContext(c.Request.Context(), "Error parsing request", "error", err)
+		res.fail(http.StatusBadRequest, models.ErrCodeInvalidRequest, "Invalid request parameters")
+		return
+	}
+
+	channel, err := h.db.GetDeletedChannel(c.Request.Context(), req.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		res.fail(http.StatusNotFound, models.ErrCodeChannelNotFound, "Deleted channel not found")
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Error getting deleted channel", "error", err)
+		res.failErr(err, http.StatusInternalServerError, "Failed to get channel")
+		return
+	}
+
+	if channel.OwnerID != req.UserID {
+		res.fail(http.StatusForbidden, models.ErrCodeNotChannelOwner, "User not permitted to restore channel")
+		return
+	}
+
+	if time.Now().UnixMilli() >= channel.DeletedAt+h.cfg.Get().Channels.RestoreGrace().Milliseconds() {
+		res.fail(http.StatusGone, models.ErrCodeRestorePeriodExpired, "Channel can no longer be restored")
+		return
+	}
+
+	// The owner may have created another channel since deleting this one
+	channels, err := h.db.GetChannelsByOwnerID(c.Request.Context(), req.UserID)
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Error getting channels", "error", err)
+		res.failErr(err, http.StatusInternalServerError, "Failed to check existing channels")
+		return
+	}
+	if len(channels) > 0 {
+		res.fail(http.StatusForbidden, models.ErrCodeQuotaExceeded, "User already has a channel")
+		return
+	}
+
+	err = h.db.RestoreChannel(c.Request.Context(), req.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		res.fail(http.StatusNotFound, models.ErrCodeChannelNotFound, "Deleted channel not found")
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Error restoring channel", "error", err)
+		res.failErr(err, http.StatusInternalServerError, "Failed to restore channel")
+		return
+	}
+	channel.DeletedAt = 0
+
+	res.ok(gin.H{
+		"channel": channel,
+	})
+}
+
+// GetChannelHistory lists the versions of a channel's configuration, newest first, each with the
+// changes it made to the version before it
+func (h *ChannelHandler) GetChannelHistory(c *gin.Context) {
+	res := respond(c, v1APIResponse)
+	var req models.ChannelHistoryRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		res.fail(http.StatusBadRequest, models.ErrCodeInvalidRequest, "Invalid request format: "+err.Error())
 		return
 	}
 
-	// Delete the channel
-	if err := h.db.DeleteChannel(req.ID); err != nil {
-		utils.LogError("Error deleting channel: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"code":    500,
-			"message": "Failed to delete channel",
+	// Set default values for limit and offset
+	if req.Limit <= 0 {
+		req.Limit = h.cfg.Get().Channels.PageSize
+	}
+	if req.Offset < 0 {
+		req.Offset = 0
+	}
+
+	if _, err := h.db.GetChannelByID(c.Request.Context(), req.ChannelID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			res.fail(http.StatusNotFound, models.ErrCodeChannelNotFound, "Channel not found")
+			return
+		}
+		slog.ErrorContext(c.Request.Context(), "Error getting channel", "error", err)
+		res.failErr(err, http.StatusInternalServerError, "Failed to get channel")
+		return
+	}
+
+	// One extra version is loaded so the oldest version of the page can be diffed
+	versions, err := h.db.GetChannelVersions(c.Request.Context(), req.ChannelID, req.Limit+1, req.Offset)
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Error getting channel versions", "error", err)
+		res.failErr(err, http.StatusInternalServerError, "Failed to get channel history")
+		return
+	}
+
+	history := []models.ChannelHistoryEntry{}
+	for i, version := range versions {
+		if i == req.Limit {
+			break
+		}
+		var previous *models.ChannelVersion
+		if i+1 < len(versions) {
+			previous = versions[i+1]
+		}
+		history = append(history, models.ChannelHistoryEntry{
+			ChannelVersion: version,
+			Changes:        models.DiffChannelVersions(previous, version),
 		})
+	}
+
+	res.ok(gin.H{
+		"versions": history,
+	})
+}
+
+// RollbackChannel restores the configuration of a channel to one of its versions, recording the
+// rollback as a new version
+func (h *ChannelHandler) RollbackChannel(c *gin.Context) {
+	res := respond(c, v1CodeMessage)
+	var req models.RollbackChannelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		slog.ErrorContext(c.Request.Context(), "Error parsing request", "error", err)
+		res.fail(http.StatusBadRequest, models.ErrCodeInvalidRequest, "Invalid request parameters")
+		return
+	}
+
+	channel, err := h.db.GetChannelByID(c.Request.Context(), req.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		res.fail(http.StatusNotFound, models.ErrCodeChannelNotFound, "Channel not found")
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Error getting channel", "error", err)
+		res.failErr(err, http.StatusInternalServerError, "Failed to get channel")
+		return
+	}
+
+	if channel.OwnerID != req.UserID {
+		res.fail(http.StatusForbidden, models.ErrCodeNotChannelOwner, "User not permitted to change channel")
+		return
+	}
+
+	version, err := h.db.GetChannelVersion(c.Request.Context(), req.ID, req.Version)
+	if errors.Is(err, sql.ErrNoRows) {
+		res.fail(http.StatusNotFound, models.ErrCodeChannelVersionNotFound, "Channel version not found")
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Error getting channel version", "error", err)
+		res.failErr(err, http.StatusInternalServerError, "Failed to get channel version")
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"code":    10000,
-		"message": "success",
-		"data": gin.H{
-			"channel": channelToDelete,
-		},
+	channel.Name = version.Name
+	channel.Description = version.Description
+	channel.IsPublic = version.IsPublic
+	channel.Watchlist = version.Watchlist
+	channel.Eventlist = version.Eventlist
+
+	// Risk fields are computed server-side, the stored ones may be stale
+	if err := h.scorer.Fill(c.Request.Context(), channel.Watchlist); err != nil {
+		slog.ErrorContext(c.Request.Context(), "Error scoring watchlist", "error", err)
+		res.failErr(err, http.StatusInternalServerError, "Failed to score watchlist")
+		return
+	}
+
+	if err := h.db.InsertOrUpdateChannel(c.Request.Context(), channel, req.UserID); err != nil {
+		slog.ErrorContext(c.Request.Context(), "Error rolling back channel", "error", err)
+		res.failErr(err, http.StatusInternalServerError, "Failed to roll back channel")
+		return
+	}
+
+	res.ok(gin.H{
+		"channel":      channel,
+		"rolledBackTo": req.Version,
 	})
 }
 
 func (h *ChannelHandler) checkAbnormalInfo(c *gin.Context, req models.CreateOrUpdateChannelRequest) (int, bool) {
+	res := respond(c, v1CodeMessage)
 	// Check Watchlist length
-	if len(req.Watchlist) > 100 {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"code":    400,
-			"message": "Watchlist exceeds the maximum limit of 100 items",
-		})
+	if limit := h.cfg.Get().Channels.WatchlistLimit; len(req.Watchlist) > limit {
+		res.fail(http.StatusBadRequest, models.ErrCodeQuotaExceeded, fmt.Sprintf("Watchlist exceeds the maximum limit of %d items", limit))
 		return 0, true
 	}
 
+	// Check CA filters
+	for _, watch := range req.Watchlist {
+		mode, cas := watch.CAFilter()
+		if mode != models.CAFilterAll && mode != models.CAFilterAny && mode != models.CAFilterList {
+			res.fail(http.StatusBadRequest, models.ErrCodeInvalidRequest, "Invalid filterCA for "+watch.TwitterName)
+			return 0, true
+		}
+		if mode == models.CAFilterList && len(cas) == 0 {
+			res.fail(http.StatusBadRequest, models.ErrCodeInvalidRequest, "filterCA list requires at least one CA for "+watch.TwitterName)
+			return 0, true
+		}
+	}
+
 	// Extract userID from header
 	userID := req.UserID
 	if userID == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"code":    400,
-			"message": "userID header is required",
-		})
+		res.fail(http.StatusBadRequest, models.ErrCodeInvalidRequest, "userID header is required")
 		return 0, true
 	}
 	return userID, false
 }
 
+// resolveWatchlist fills watchlist entries with canonical account data, writing the error response
+// and returning false when an entry cannot be resolved
+func (h *ChannelHandler) resolveWatchlist(c *gin.Context, watchlist []models.Watchlist) bool {
+	res := respond(c, v1CodeMessage)
+	err := h.resolver.Resolve(c.Request.Context(), watchlist)
+	if err == nil {
+		return true
+	}
+
+	var unknown *twitter.UnknownUserError
+	if errors.As(err, &unknown) {
+		res.fail(http.StatusBadRequest, models.ErrCodeUnknownTwitterUser, "Unknown Twitter user: "+unknown.User)
+		return false
+	}
+
+	slog.ErrorContext(c.Request.Context(), "Error resolving watchlist", "error", err)
+	res.failErr(err, http.StatusBadGateway, "Failed to resolve Twitter users")
+	return false
+}
+
 func (h *ChannelHandler) FollowChannel(c *gin.Context) {
+	res := respond(c, v1CodeMessage)
 	var req models.FollowRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		utils.LogError("Error parsing request: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{
-			"code":    400,
-			"message": "Invalid request parameters",
-		})
+		slog.ErrorContext(c.Request.Context(), "Error parsing request", "error", err)
+		res.fail(http.StatusBadRequest, models.ErrCodeInvalidRequest, "Invalid request parameters")
 		return
 	}
 
 	// Check if userID is provided
 	if req.UserID == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"code":    400,
-			"message": "userID is required",
-		})
+		res.fail(http.StatusBadRequest, models.ErrCodeInvalidRequest, "userID is required")
 		return
 	}
 
 	// Check if the channel exists
-	channels, err := h.db.GetChannelsByID(req.ID)
+	channels, err := h.db.GetChannelsByID(c.Request.Context(), req.ID)
 	if err != nil {
-		utils.LogError("Error getting channels: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"code":    500,
-			"message": "Failed to check channel existence",
-		})
+		slog.ErrorContext(c.Request.Context(), "Error getting channels", "error", err)
+		res.failErr(err, http.StatusInternalServerError, "Failed to check channel existence")
 		return
 	}
 
-	log.Printf("Channels: %+v", channels)
 	// Check if channel exists
 	channelExists := false
 	for _, channel := range channels {
@@ -329,132 +533,95 @@ func (h *ChannelHandler) FollowChannel(c *gin.Context) {
 	}
 
 	if !channelExists {
-		c.JSON(http.StatusNotFound, gin.H{
-			"code":    404,
-			"message": "Channel not found",
-		})
+		res.fail(http.StatusNotFound, models.ErrCodeChannelNotFound, "Channel not found")
 		return
 	}
 
-	// Check if already following
-	isFollowing, err := h.db.IsFollowing(req.UserID, req.ID)
-	if err != nil {
-		utils.LogError("Error checking follow status: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"code":    500,
-			"message": "Failed to check follow status",
-		})
-		return
-	}
-
-	if isFollowing {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"code":    400,
-			"message": "Already following this channel",
-		})
-		return
-	}
-
-	// Create follow relationship
+	// Create follow relationship; following again is not an error
 	follow := &models.Follow{
 		ID:        uuid.New().String(),
 		UserID:    req.UserID,
 		ChannelID: req.ID,
 	}
 
-	if err := h.db.FollowChannel(follow); err != nil {
-		utils.LogError("Error following channel: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"code":    500,
-			"message": "Failed to follow channel",
-		})
+	created, err := h.db.FollowChannel(c.Request.Context(), follow)
+	if errors.Is(err, sql.ErrNoRows) {
+		res.fail(http.StatusNotFound, models.ErrCodeChannelNotFound, "Channel not found")
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Error following channel", "error", err)
+		res.failErr(err, http.StatusInternalServerError, "Failed to follow channel")
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"code":    10000,
-		"message": "success",
-		"data": gin.H{
-			"follow": follow,
-		},
+	if !created {
+		// Return the existing follow
+		follows, err := h.db.GetFollowedChannels(c.Request.Context(), req.UserID)
+		if err != nil {
+			slog.ErrorContext(c.Request.Context(), "Error getting follows", "error", err)
+			res.failErr(err, http.StatusInternalServerError, "Failed to get follow")
+			return
+		}
+		for _, existing := range follows {
+			if existing.ChannelID == req.ID {
+				follow = existing
+				break
+			}
+		}
+	}
+
+	res.ok(gin.H{
+		"follow":           follow,
+		"alreadyFollowing": !created,
 	})
 }
 
 func (h *ChannelHandler) UnfollowChannel(c *gin.Context) {
+	res := respond(c, v1CodeMessage)
 	var req models.UnfollowRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		utils.LogError("Error parsing request: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{
-			"code":    400,
-			"message": "Invalid request parameters",
-		})
+		slog.ErrorContext(c.Request.Context(), "Error parsing request", "error", err)
+		res.fail(http.StatusBadRequest, models.ErrCodeInvalidRequest, "Invalid request parameters")
 		return
 	}
 
 	// Check if userID is provided
 	if req.UserID == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"code":    400,
-			"message": "userID is required",
-		})
+		res.fail(http.StatusBadRequest, models.ErrCodeInvalidRequest, "userID is required")
 		return
 	}
 
-	// Check if user is following the channel
-	isFollowing, err := h.db.IsFollowing(req.UserID, req.ID)
-	if err != nil {
-		utils.LogError("Error checking follow status: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"code":    500,
-			"message": "Failed to check follow status",
-		})
+	// Unfollow the channel; unfollowing a channel that is not followed is not an error
+	removed, err := h.db.UnfollowChannel(c.Request.Context(), req.UserID, req.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		res.fail(http.StatusNotFound, models.ErrCodeChannelNotFound, "Channel not found")
 		return
 	}
-
-	if !isFollowing {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"code":    400,
-			"message": "Not following this channel",
-		})
-		return
-	}
-
-	// Unfollow the channel
-	if err := h.db.UnfollowChannel(req.UserID, req.ID); err != nil {
-		utils.LogError("Error unfollowing channel: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"code":    500,
-			"message": "Failed to unfollow channel",
-		})
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Error unfollowing channel", "error", err)
+		res.failErr(err, http.StatusInternalServerError, "Failed to unfollow channel")
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"code":    10000,
-		"message": "success",
-		"data": gin.H{
-			"channelId": req.ID,
-			"userId":    req.UserID,
-		},
+	res.ok(gin.H{
+		"channelId":    req.ID,
+		"userId":       req.UserID,
+		"wasFollowing": removed,
 	})
 }
 
 func (h *ChannelHandler) GetChannelList(c *gin.Context) {
+	res := respond(c, v1APIResponse)
 	var req models.ChannelListRequest
 	if err := c.ShouldBindQuery(&req); err != nil {
-		c.JSON(http.StatusBadRequest, models.APIResponse{
-			Success: false,
-			Error: &models.APIError{
-				Code:    "400",
-				Message: "Invalid request format: " + err.Error(),
-			},
-		})
+		res.fail(http.StatusBadRequest, models.ErrCodeInvalidRequest, "Invalid request format: "+err.Error())
 		return
 	}
 
 	// Set default values for limit and offset
 	if req.Limit <= 0 {
-		req.Limit = 50
+		req.Limit = h.cfg.Get().Channels.PageSize
 	}
 	if req.Offset < 0 {
 		req.Offset = 0
@@ -466,18 +633,12 @@ func (h *ChannelHandler) GetChannelList(c *gin.Context) {
 	switch req.Type {
 	case "1":
 		// Get channels by owner ID
-		channels, err = h.db.GetChannelsByOwnerID(req.UserID)
+		channels, err = h.db.GetChannelsByOwnerID(c.Request.Context(), req.UserID)
 	case "2":
 		// Get followed channels
-		follows, err := h.db.GetFollowedChannels(req.UserID)
+		follows, err := h.db.GetFollowedChannels(c.Request.Context(), req.UserID)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, models.APIResponse{
-				Success: false,
-				Error: &models.APIError{
-					Code:    "500",
-					Message: "Failed to get followed channels",
-				},
-			})
+			res.failErr(err, http.StatusInternalServerError, "Failed to get followed channels")
 			return
 		}
 
@@ -488,33 +649,26 @@ func (h *ChannelHandler) GetChannelList(c *gin.Context) {
 		}
 
 		// Get all channels in a single query
-		channels, err = h.db.GetChannelByIDs(channelIDs)
+		channels, err = h.db.GetChannelByIDs(c.Request.Context(), channelIDs)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, models.APIResponse{
-				Success: false,
-				Error: &models.APIError{
-					Code:    "500",
-					Message: "Failed to get channels",
-				},
-			})
+			res.failErr(err, http.StatusInternalServerError, "Failed to get channels")
 			return
 		}
 	default:
 		// Get all channels
-		channels, err = h.db.GetAllChannels(req.Limit, req.Offset)
+		channels, err = h.db.GetAllChannels(c.Request.Context(), req.Limit, req.Offset)
 	}
 
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.APIResponse{
-			Success: false,
-			Error: &models.APIError{
-				Code:    "500",
-				Message: "Failed to get channels",
-			},
-		})
+		res.failErr(err, http.StatusInternalServerError, "Failed to get channels")
 		return
 	}
 
+	// Show the cached account metadata rather than what was stored with each watchlist
+	if err := h.hydrateWatchlists(c.Request.Context(), channels); err != nil {
+		slog.ErrorContext(c.Request.Context(), "Failed to hydrate watchlists", "error", err)
+	}
+
 	// Apply pagination
 	total := len(channels)
 	start := req.Offset
@@ -550,7 +704,7 @@ func (h *ChannelHandler) GetChannelList(c *gin.Context) {
 				HotExpireAt:   channel.HotExpireAt,
 				IsVerified:    channel.IsVerified,
 				Eventlist:     channel.Eventlist,
-				FollowerCount: channel.FollowerCount,
+				FollowerCount: strconv.Itoa(channel.FollowerCount),
 				ID:            channel.ID,
 				Name:          channel.Name,
 				OwnerID:       channel.OwnerID,
@@ -566,24 +720,65 @@ func (h *ChannelHandler) GetChannelList(c *gin.Context) {
 		})
 	}
 
-	c.JSON(http.StatusOK, models.APIResponse{
-		Success: true,
-		Data: map[string]interface{}{
-			"channels": responseChannels,
-			"total":    total,
-		},
+	res.ok(map[string]interface{}{
+		"channels": responseChannels,
+		"total":    total,
 	})
 }
 
+// hydrateWatchlists replaces the display and risk fields of watchlist entries with the values
+// cached per account, so every channel watching an account shows the same, current data
+func (h *ChannelHandler) hydrateWatchlists(ctx context.Context, channels []*models.Channel) error {
+	var twitterIds []string
+	seen := make(map[string]bool)
+	for _, channel := range channels {
+		for _, watch := range channel.Watchlist {
+			if watch.TwitterId != "" && !seen[watch.TwitterId] {
+				seen[watch.TwitterId] = true
+				twitterIds = append(twitterIds, watch.TwitterId)
+			}
+		}
+	}
+
+	accounts, err := h.db.GetTwitterAccounts(ctx, twitterIds)
+	if err != nil {
+		return err
+	}
+	scores, err := h.db.GetRiskScores(ctx, twitterIds)
+	if err != nil {
+		return err
+	}
+
+	for _, channel := range channels {
+		for i := range channel.Watchlist {
+			watch := &channel.Watchlist[i]
+			if account, ok := accounts[watch.TwitterId]; ok {
+				watch.TwitterName = account.UserName
+				watch.TwitterAvatar = account.Avatar
+				watch.TwitterVerify = strconv.FormatBool(account.Verified)
+			}
+			if score, ok := scores[watch.TwitterId]; ok {
+				watch.Risk = score.Score
+				watch.TwitterRisk = score.Level
+			}
+		}
+	}
+	return nil
+}
+
 // fetchMarketInfo fetches market info for a given chain ID and token CA
-func fetchMarketInfo(chainId, tokenCa string) (interface{}, error) {
+func (h *ChannelHandler) fetchMarketInfo(ctx context.Context, chainId, tokenCa string) (_ interface{}, err error) {
 	if chainId == "" || tokenCa == "" {
 		return nil, nil
 	}
+	ctx, span := tracing.Start(ctx, "market.fetchMarketInfo", attribute.String("chainId", chainId), attribute.String("tokenCa", tokenCa))
+	defer tracing.End(span, &err)
 
-	url := fmt.Sprintf("https://api.litrocket.io/v1/market/market_info?chain_id=%s&token_ca=%s", chainId, tokenCa)
-	log.Println(url)
-	req, err := http.NewRequest("GET", url, nil)
+	market := h.cfg.Get().Market
+	query := url.Values{"chain_id": {chainId}, "token_ca": {tokenCa}}
+	endpoint := strings.TrimSuffix(market.BaseURL, "/") + "/v1/market/market_info?" + query.Encode()
+	slog.DebugContext(ctx, "Fetching market info", "url", endpoint)
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -593,7 +788,7 @@ func fetchMarketInfo(chainId, tokenCa string) (interface{}, error) {
 	req.Header.Set("X-Source", "ios")
 	req.Header.Set("Qlbl69aq2dxo4t", "1")
 
-	client := &http.Client{}
+	client := &http.Client{Timeout: market.Timeout, Transport: metrics.Transport("market", tracing.Transport("market", nil))}
 	resp, err := client.Do(req)
 	if err != nil {
 		return nil, err
@@ -615,170 +810,104 @@ func fetchMarketInfo(chainId, tokenCa string) (interface{}, error) {
 }
 
 func (h *ChannelHandler) GetChannelContent(c *gin.Context) {
+	res := respond(c, v1APIResponse)
 	var req models.ChannelContentRequest
 	if err := c.ShouldBindQuery(&req); err != nil {
-		c.JSON(http.StatusBadRequest, models.APIResponse{
-			Success: false,
-			Error: &models.APIError{
-				Code:    "400",
-				Message: "Invalid request format: " + err.Error(),
-			},
-		})
+		res.fail(http.StatusBadRequest, models.ErrCodeInvalidRequest, "Invalid request format: "+err.Error())
 		return
 	}
 
 	// Set default values for limit and offset
 	if req.Limit <= 0 {
-		req.Limit = 50 // Default limit
+		req.Limit = h.cfg.Get().Channels.PageSize
 	}
 	if req.Offset < 0 {
 		req.Offset = 0 // Default offset
 	}
 
 	// Get channels for the user
-	channels, err := h.db.GetChannelsByID(req.ChannelID)
+	channels, err := h.db.GetChannelsByID(c.Request.Context(), req.ChannelID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.APIResponse{
-			Success: false,
-			Error: &models.APIError{
-				Code:    "500",
-				Message: "Failed to get channels",
-			},
-		})
+		res.failErr(err, http.StatusInternalServerError, "Failed to get channels")
 		return
 	}
 
 	if len(channels) == 0 {
-		c.JSON(http.StatusNotFound, models.APIResponse{
-			Success: false,
-			Error: &models.APIError{
-				Code:    "404",
-				Message: "Channel not found",
-			},
-		})
+		res.fail(http.StatusNotFound, models.ErrCodeChannelNotFound, "Channel not found")
 		return
 	}
 
 	channel := channels[0]
-	var twitterInfos []*models.TwitterInfo
 
-	if req.ContentType == 1 {
-		// Build conditions for tweets
-		var conditions []string
-		for _, watch := range channel.Watchlist {
-			log.Printf("watch: %+v", watch)
-			if !watch.Tweets {
-				continue
-			}
-			condition := fmt.Sprintf("(twitterId = '%s'", watch.TwitterId)
-			if watch.CA != "" {
-				condition += fmt.Sprintf(" AND address = '%s'", watch.CA)
+	// Select the watched accounts whose content of the requested type is shown
+	var watched []models.Watchlist
+	for _, watch := range channel.Watchlist {
+		switch req.ContentType {
+		case models.TwitterInfoTypeTweet:
+			if watch.Tweets {
+				watched = append(watched, watch)
 			}
-			condition += ")"
-			conditions = append(conditions, condition)
-		}
-
-		twitterInfos, err = h.db.GetTwitterInfoByWatchlist(conditions, req.ContentType, req.Limit, req.Offset)
-		if err != nil {
-			utils.LogError("Failed to get Twitter info: %v", err)
-			c.JSON(http.StatusInternalServerError, models.APIResponse{
-				Success: false,
-				Error: &models.APIError{
-					Code:    "500",
-					Message: "Failed to get Twitter info",
-				},
-			})
-			return
-		}
-	} else if req.ContentType == 2 {
-		// Build conditions for profile updates and follows
-		var twitterIds []string
-		for _, watch := range channel.Watchlist {
+		case models.TwitterInfoTypeUpdate:
 			if watch.ProfileUpdate && watch.Follows {
-				twitterIds = append(twitterIds, watch.TwitterId)
+				watched = append(watched, watch)
 			}
 		}
+	}
 
-		twitterInfos, err = h.db.GetTwitterInfoByProfileAndFollow(twitterIds, req.ContentType, req.Limit, req.Offset)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, models.APIResponse{
-				Success: false,
-				Error: &models.APIError{
-					Code:    "500",
-					Message: "Failed to get Twitter info",
-				},
-			})
-			return
-		}
+	query := database.NewContentQuery(watched, req.ContentType, req.Limit, req.Offset)
+	twitterInfos, err := h.db.GetTwitterInfo(c.Request.Context(), query)
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Failed to get Twitter info", "error", err)
+		res.failErr(err, http.StatusInternalServerError, "Failed to get Twitter info")
+		return
 	}
 
-	// Get market info for each Twitter info
+	// Get market info for each Twitter info, unless the feature is turned off
 	marketInfos := make([]interface{}, len(twitterInfos))
-	for i, info := range twitterInfos {
-		marketInfo, err := fetchMarketInfo(info.ChainId, info.Address)
+	if h.cfg.Get().Features.MarketInfo {
+		for i, info := range twitterInfos {
+			marketInfo, err := h.fetchMarketInfo(c.Request.Context(), info.ChainId, info.Address)
 
-		if err != nil {
-			utils.LogError("Failed to fetch market info: %v", err)
-			marketInfos[i] = nil
-			continue
+			if err != nil {
+				slog.ErrorContext(c.Request.Context(), "Failed to fetch market info", "error", err)
+				marketInfos[i] = nil
+				continue
+			}
+			marketInfos[i] = marketInfo
 		}
-		marketInfos[i] = marketInfo
 	}
 
 	// Return combined response
-	c.JSON(http.StatusOK, models.APIResponse{
-		Success: true,
-		Data: map[string]interface{}{
-			"twitter": twitterInfos,
-			"market":  marketInfos,
-			"total":   len(channels),
-		},
+	res.ok(map[string]interface{}{
+		"twitter": twitterInfos,
+		"market":  marketInfos,
+		"total":   len(channels),
 	})
 }
 
 func (h *ChannelHandler) TwitterInfo(c *gin.Context) {
+	res := respond(c, v1APIResponse)
 	user := c.Query("user")
 	if user == "" {
-		c.JSON(http.StatusBadRequest, models.APIResponse{
-			Success: false,
-			Error: &models.APIError{
-				Code:    "400",
-				Message: "user parameter is required",
-			},
-		})
+		res.fail(http.StatusBadRequest, models.ErrCodeInvalidRequest, "user parameter is required")
 		return
 	}
 
-	url := fmt.Sprintf("http://43.160.199.161:5188/tw_user_info?user=%s&token=test0623", user)
-	resp, err := http.Get(url)
-	if err != nil {
-		utils.LogError("Failed to call Twitter info API: %v", err)
-		c.JSON(http.StatusInternalServerError, models.APIResponse{
-			Success: false,
-			Error: &models.APIError{
-				Code:    "500",
-				Message: "Failed to fetch Twitter info",
-			},
-		})
+	result, err := h.twitterClient.RawUserInfo(c.Request.Context(), user)
+	if errors.Is(err, twitter.ErrUserNotFound) {
+		res.fail(http.StatusNotFound, models.ErrCodeUnknownTwitterUser, "Unknown Twitter user: "+user)
 		return
 	}
-	defer resp.Body.Close()
-
-	var result interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		utils.LogError("Failed to decode response: %v", err)
-		c.JSON(http.StatusInternalServerError, models.APIResponse{
-			Success: false,
-			Error: &models.APIError{
-				Code:    "500",
-				Message: "Failed to parse response",
-			},
-		})
+	if errors.Is(err, twitter.ErrUpstream) {
+		slog.ErrorContext(c.Request.Context(), "Twitter info API failed", "error", err)
+		res.failErr(err, http.StatusBadGateway, "Failed to fetch Twitter info")
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Failed to call Twitter info API", "error", err)
+		res.failErr(err, http.StatusInternalServerError, "Failed to fetch Twitter info")
 		return
 	}
 
-	c.JSON(http.StatusOK, models.APIResponse{
-		Success: true,
-		Data:    result,
-	})
+	res.ok(result)
 }
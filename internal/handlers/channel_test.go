@@ -0,0 +1,347 @@
+package handlers
+
+import (
+	"TwitterMonitor/config"
+	"TwitterMonitor/internal/database"
+	"TwitterMonitor/internal/twitter"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// testUsers are the accounts known to the fake user-info service, by name
+var testUsers = map[string]string{"alice": "101", "bob": "102"}
+
+// testIngestToken is the ingest token of the test router
+const testIngestToken = "crawler-token"
+
+// newTestRouter serves the channel, notification and twitter APIs on /v1 and /v2 from a
+// memory store, resolving watchlists against a fake user-info service
+func newTestRouter(t *testing.T) (*gin.Engine, *database.MemoryDatabase) {
+	t.Helper()
+	db := database.NewMemoryDatabase()
+	return newTestRouterOn(t, db), db
+}
+
+// newTestRouterOn is newTestRouter serving from db
+func newTestRouterOn(t *testing.T, db database.Store) *gin.Engine {
+	t.Helper()
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := r.URL.Query().Get("user")
+		id, ok := testUsers[name]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprintf(w, `{"rest_id":%q,"screen_name":%q}`, id, name)
+	}))
+	t.Cleanup(upstream.Close)
+
+	live, _, err := config.LoadLive([]string{
+		"--database-url", "memory://",
+		"--twitter-base-url", upstream.URL,
+		"--feature-market-info=false",
+		"--ingest-token", testIngestToken,
+	})
+	if err != nil {
+		t.Fatalf("LoadLive: %v", err)
+	}
+
+	channelHandler := NewChannelHandler(db, twitter.NewClient(upstream.URL, "token", live.Get().Twitter.Timeout), live)
+	notificationHandler := NewNotificationHandler(db, live)
+	twitterHandler := NewTwitterHandler(db)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	for version := 1; version <= 2; version++ {
+		api := router.Group(fmt.Sprintf("/v%d", version), APIVersion(version))
+		api.POST("/channel/create", channelHandler.CreateChannel)
+		api.POST("/channel/delete", channelHandler.DeleteChannel)
+		api.POST("/channel/follow", channelHandler.FollowChannel)
+		api.POST("/channel/unfollow", channelHandler.UnfollowChannel)
+		api.GET("/channel/channel_list", channelHandler.GetChannelList)
+		api.GET("/channel/channel_content", channelHandler.GetChannelContent)
+		api.GET("/notification/list", notificationHandler.GetNotifications)
+		api.POST("/twitter/ingest", IngestAuth(live), twitterHandler.IngestTwitterInfo)
+		api.POST("/twitter/delete", IngestAuth(live), twitterHandler.DeleteTwitterInfo)
+	}
+	return router
+}
+
+// call sends a request to router and decodes the JSON response
+func call(t *testing.T, router *gin.Engine, method, path string, body interface{}) (int, map[string]interface{}) {
+	t.Helper()
+	return callWithToken(t, router, "", method, path, body)
+}
+
+// callWithToken is call with a bearer token, left out when empty
+func callWithToken(t *testing.T, router *gin.Engine, token, method, path string, body interface{}) (int, map[string]interface{}) {
+	t.Helper()
+	var reader *bytes.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			t.Fatalf("encode request: %v", err)
+		}
+		reader = bytes.NewReader(encoded)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+	req := httptest.NewRequest(method, path, reader)
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &decoded); err != nil {
+		t.Fatalf("%s %s: decode %q: %v", method, path, rec.Body.String(), err)
+	}
+	return rec.Code, decoded
+}
+
+// createRequest is a valid create request for ownerID watching the named accounts
+func createRequest(ownerID int, names ...string) map[string]interface{} {
+	watchlist := []map[string]interface{}{}
+	for _, name := range names {
+		watchlist = append(watchlist, map[string]interface{}{
+			"twitterName": name, "tweets": true, "profileUpdate": true, "follows": true,
+		})
+	}
+	return map[string]interface{}{
+		"userId": ownerID, "name": "alpha", "avatar": "a.png", "description": "calls",
+		"chatLink": "https://t.me/alpha", "watchlist": watchlist, "eventlist": []interface{}{},
+	}
+}
+
+// createChannel creates a channel through /v2 and returns its ID
+func createChannel(t *testing.T, router *gin.Engine, ownerID int, names ...string) string {
+	t.Helper()
+	status, body := call(t, router, http.MethodPost, "/v2/channel/create", createRequest(ownerID, names...))
+	if status != http.StatusOK {
+		t.Fatalf("create channel: %d %v", status, body)
+	}
+	channel := body["data"].(map[string]interface{})["channel"].(map[string]interface{})
+	return channel["id"].(string)
+}
+
+// listChannels returns the channels of a /v2 channel list
+func listChannels(t *testing.T, router *gin.Engine, query string) []interface{} {
+	t.Helper()
+	status, body := call(t, router, http.MethodGet, "/v2/channel/channel_list?"+query, nil)
+	if status != http.StatusOK {
+		t.Fatalf("list channels: %d %v", status, body)
+	}
+	channels, _ := body["data"].(map[string]interface{})["channels"].([]interface{})
+	return channels
+}
+
+// followerCount returns the follower count of the only channel
+func followerCount(t *testing.T, router *gin.Engine) string {
+	t.Helper()
+	channels := listChannels(t, router, "type=all")
+	if len(channels) != 1 {
+		t.Fatalf("listed %d channels, want 1", len(channels))
+	}
+	return channels[0].(map[string]interface{})["meta"].(map[string]interface{})["followerCount"].(string)
+}
+
+func TestCreateChannel(t *testing.T) {
+	router, _ := newTestRouter(t)
+
+	status, body := call(t, router, http.MethodPost, "/v1/channel/create", createRequest(7, "alice"))
+	if status != http.StatusOK || body["code"] != float64(10000) {
+		t.Fatalf("create: %d %v", status, body)
+	}
+	watch := body["data"].(map[string]interface{})["channel"].(map[string]interface{})["watchlist"].([]interface{})[0].(map[string]interface{})
+	if watch["twitterId"] != "101" {
+		t.Errorf("watchlist entry resolved to %v, want twitterId 101", watch["twitterId"])
+	}
+
+	// One channel per owner
+	status, body = call(t, router, http.MethodPost, "/v1/channel/create", createRequest(7, "bob"))
+	if status != http.StatusForbidden || body["code"] != float64(http.StatusForbidden) {
+		t.Errorf("second create: %d %v, want 403", status, body)
+	}
+	status, body = call(t, router, http.MethodPost, "/v2/channel/create", createRequest(7, "bob"))
+	if status != http.StatusForbidden || body["error"].(map[string]interface{})["code"] != "QUOTA_EXCEEDED" {
+		t.Errorf("second create on /v2: %d %v, want 403 QUOTA_EXCEEDED", status, body)
+	}
+
+	// Watchlist entries must resolve
+	status, body = call(t, router, http.MethodPost, "/v2/channel/create", createRequest(8, "ghost"))
+	if status != http.StatusUnprocessableEntity || body["error"].(map[string]interface{})["code"] != "UNKNOWN_TWITTER_USER" {
+		t.Errorf("create with unknown user: %d %v, want 422 UNKNOWN_TWITTER_USER", status, body)
+	}
+
+	if channels := listChannels(t, router, "type=1&userId=7"); len(channels) != 1 {
+		t.Errorf("owner lists %d channels, want 1", len(channels))
+	}
+	if channels := listChannels(t, router, "type=all"); len(channels) != 1 {
+		t.Errorf("listed %d channels, want 1", len(channels))
+	}
+}
+
+func TestFollowAndUnfollow(t *testing.T) {
+	router, _ := newTestRouter(t)
+	id := createChannel(t, router, 7, "alice")
+
+	for _, userID := range []int{3, 4, 3} {
+		status, body := call(t, router, http.MethodPost, "/v1/channel/follow", gin.H{"userId": userID, "id": id})
+		if status != http.StatusOK {
+			t.Fatalf("user %d follow: %d %v", userID, status, body)
+		}
+	}
+	if count := followerCount(t, router); count != "2" {
+		t.Errorf("follower count after following twice = %s, want 2", count)
+	}
+
+	status, body := call(t, router, http.MethodPost, "/v2/channel/follow", gin.H{"userId": 3, "id": id})
+	if status != http.StatusOK || body["data"].(map[string]interface{})["alreadyFollowing"] != true {
+		t.Errorf("follow again: %d %v, want alreadyFollowing", status, body)
+	}
+	if channels := listChannels(t, router, "type=2&userId=3"); len(channels) != 1 {
+		t.Errorf("user 3 follows %d channels, want 1", len(channels))
+	}
+
+	for i, want := range []bool{true, false} {
+		status, body := call(t, router, http.MethodPost, "/v2/channel/unfollow", gin.H{"userId": 3, "id": id})
+		if status != http.StatusOK || body["data"].(map[string]interface{})["wasFollowing"] != want {
+			t.Errorf("unfollow #%d: %d %v, want wasFollowing %v", i+1, status, body, want)
+		}
+	}
+	if count := followerCount(t, router); count != "1" {
+		t.Errorf("follower count after unfollowing = %s, want 1", count)
+	}
+	if channels := listChannels(t, router, "type=2&userId=3"); len(channels) != 0 {
+		t.Errorf("user 3 still follows %d channels", len(channels))
+	}
+
+	status, body = call(t, router, http.MethodPost, "/v2/channel/follow", gin.H{"userId": 3, "id": "missing"})
+	if status != http.StatusNotFound || body["error"].(map[string]interface{})["code"] != "CHANNEL_NOT_FOUND" {
+		t.Errorf("follow missing channel: %d %v, want 404 CHANNEL_NOT_FOUND", status, body)
+	}
+}
+
+func TestDeleteNotifiesFollowers(t *testing.T) {
+	router, _ := newTestRouter(t)
+	id := createChannel(t, router, 7, "alice")
+	call(t, router, http.MethodPost, "/v1/channel/follow", gin.H{"userId": 3, "id": id})
+
+	status, body := call(t, router, http.MethodPost, "/v1/channel/delete", gin.H{"userId": 9, "id": id})
+	if status != http.StatusNotFound || body["code"] != float64(http.StatusForbidden) {
+		t.Errorf("delete by another user: %d %v, want 404 with code 403", status, body)
+	}
+	status, body = call(t, router, http.MethodPost, "/v1/channel/delete", gin.H{"userId": 7, "id": id})
+	if status != http.StatusOK {
+		t.Fatalf("delete: %d %v", status, body)
+	}
+	if channels := listChannels(t, router, "type=all"); len(channels) != 0 {
+		t.Errorf("deleted channel still listed")
+	}
+
+	status, body = call(t, router, http.MethodGet, "/v2/notification/list?userId=3", nil)
+	if status != http.StatusOK {
+		t.Fatalf("notifications: %d %v", status, body)
+	}
+	notifications, _ := body["data"].(map[string]interface{})["notifications"].([]interface{})
+	if len(notifications) != 1 {
+		t.Errorf("follower got %d notifications, want 1", len(notifications))
+	}
+}
+
+func TestDeleteRacingDelete(t *testing.T) {
+	for version, check := range map[string]func(status int, body map[string]interface{}) bool{
+		"v1": func(status int, body map[string]interface{}) bool {
+			return status == http.StatusNotFound && body["code"] == float64(http.StatusForbidden)
+		},
+		"v2": func(status int, body map[string]interface{}) bool {
+			return status == http.StatusNotFound && body["error"].(map[string]interface{})["code"] == "CHANNEL_NOT_FOUND"
+		},
+	} {
+		t.Run(version, func(t *testing.T) {
+			// Another request deletes the channel between the ownership check and the delete
+			db := database.NewMemoryDatabase()
+			racing := database.WithHook(db, func(ctx context.Context, op string, write bool, call func(context.Context) error) error {
+				if op == "DeleteChannel" {
+					channels, _ := db.GetAllChannels(ctx, 0, 0)
+					for _, channel := range channels {
+						db.DeleteChannel(ctx, channel.ID, 1)
+					}
+				}
+				return call(ctx)
+			})
+			router := newTestRouterOn(t, racing)
+			id := createChannel(t, router, 7, "alice")
+
+			status, body := call(t, router, http.MethodPost, "/"+version+"/channel/delete", gin.H{"userId": 7, "id": id})
+			if !check(status, body) {
+				t.Errorf("delete of a channel deleted meanwhile: %d %v, want 404 channel not found", status, body)
+			}
+		})
+	}
+}
+
+func TestIngestKeepsTweetsIdUnique(t *testing.T) {
+	router, _ := newTestRouter(t)
+	id := createChannel(t, router, 7, "alice")
+
+	for _, content := range []string{"first", "edited"} {
+		status, body := callWithToken(t, router, testIngestToken, http.MethodPost, "/v1/twitter/ingest", gin.H{"items": []gin.H{
+			{"tweetsId": "t1", "twitterId": "101", "content": content, "type": 1},
+		}})
+		if status != http.StatusOK {
+			t.Fatalf("ingest: %d %v", status, body)
+		}
+	}
+	callWithToken(t, router, testIngestToken, http.MethodPost, "/v1/twitter/ingest", gin.H{"items": []gin.H{
+		{"tweetsId": "t2", "twitterId": "999", "content": "not watched", "type": 1},
+	}})
+
+	status, body := call(t, router, http.MethodGet, "/v2/channel/channel_content?contentType=1&channelId="+id, nil)
+	if status != http.StatusOK {
+		t.Fatalf("channel content: %d %v", status, body)
+	}
+	tweets, _ := body["data"].(map[string]interface{})["twitter"].([]interface{})
+	if len(tweets) != 1 {
+		t.Fatalf("channel shows %d tweets, want 1: %v", len(tweets), tweets)
+	}
+	if content := tweets[0].(map[string]interface{})["content"]; content != "edited" {
+		t.Errorf("tweet content = %v, want the re-ingested content", content)
+	}
+}
+
+func TestIngestRequiresToken(t *testing.T) {
+	router, _ := newTestRouter(t)
+	id := createChannel(t, router, 7, "alice")
+	items := gin.H{"items": []gin.H{{"tweetsId": "t1", "twitterId": "101", "content": "forged", "type": 1}}}
+
+	for _, path := range []string{"/v1/twitter/ingest", "/v2/twitter/ingest", "/v1/twitter/delete", "/v2/twitter/delete"} {
+		for _, token := range []string{"", "wrong"} {
+			status, body := callWithToken(t, router, token, http.MethodPost, path, items)
+			if status != http.StatusUnauthorized {
+				t.Errorf("%s with token %q: %d %v, want 401", path, token, status, body)
+			}
+		}
+	}
+	status, body := call(t, router, http.MethodPost, "/v2/twitter/ingest", items)
+	if status != http.StatusUnauthorized || body["error"].(map[string]interface{})["code"] != "UNAUTHORIZED" {
+		t.Errorf("unauthenticated ingest on /v2: %d %v, want 401 UNAUTHORIZED", status, body)
+	}
+
+	status, body = call(t, router, http.MethodGet, "/v2/channel/channel_content?contentType=1&channelId="+id, nil)
+	if status != http.StatusOK {
+		t.Fatalf("channel content: %d %v", status, body)
+	}
+	if tweets, _ := body["data"].(map[string]interface{})["twitter"].([]interface{}); len(tweets) != 0 {
+		t.Errorf("unauthenticated ingest stored %d tweets", len(tweets))
+	}
+}
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"TwitterMonitor/internal/models"
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// StatusClientClosedRequest is answered, as by nginx, when the client went away before the
+// response was ready; the client never sees it but the access log and metrics do
+const StatusClientClosedRequest = 499
+
+// errorStatuses is the status answered with each error code in /v2
+var errorStatuses = map[string]int{
+	models.ErrCodeInvalidRequest:         http.StatusBadRequest,
+	models.ErrCodeUnauthorized:           http.StatusUnauthorized,
+	models.ErrCodeForbidden:              http.StatusForbidden,
+	models.ErrCodeUnknownTwitterUser:     http.StatusUnprocessableEntity,
+	models.ErrCodeQuotaExceeded:          http.StatusForbidden,
+	models.ErrCodeNotChannelOwner:        http.StatusForbidden,
+	models.ErrCodeChannelNotFound:        http.StatusNotFound,
+	models.ErrCodeChannelVersionNotFound: http.StatusNotFound,
+	models.ErrCodeRestorePeriodExpired:   http.StatusGone,
+	models.ErrCodeClientClosedRequest:    StatusClientClosedRequest,
+	models.ErrCodeInternal:               http.StatusInternalServerError,
+	models.ErrCodeUpstream:               http.StatusBadGateway,
+	models.ErrCodeTimeout:                http.StatusGatewayTimeout,
+}
+
+// statusErrorCodes is the error code of a failed operation answered with each status
+var statusErrorCodes = map[int]string{
+	StatusClientClosedRequest:      models.ErrCodeClientClosedRequest,
+	http.StatusInternalServerError: models.ErrCodeInternal,
+	http.StatusBadGateway:          models.ErrCodeUpstream,
+	http.StatusGatewayTimeout:      models.ErrCodeTimeout,
+}
+
+// errorStatus returns the status answering a request that failed with err: 504 when a store
+// operation or upstream call timed out, 499 when the client cancelled the request, and
+// fallback for other failures
+func errorStatus(c *gin.Context, err error, fallback int) int {
+	var timeout interface{ Timeout() bool }
+	switch {
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &timeout) && timeout.Timeout():
+		return http.StatusGatewayTimeout
+	case errors.Is(err, context.Canceled), c.Request.Context().Err() != nil:
+		return StatusClientClosedRequest
+	}
+	return fallback
+}
@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"TwitterMonitor/config"
+	"TwitterMonitor/internal/database"
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Readiness states, of a check and of the server as a whole
+const (
+	StatusUp          = "up"
+	StatusDown        = "down"
+	StatusReady       = "ready"
+	StatusDegraded    = "degraded"
+	StatusUnavailable = "unavailable"
+)
+
+// DependencyStatus is the result of one readiness check. Optional dependencies being down
+// degrades the server without making it unready
+type DependencyStatus struct {
+	Status    string      `json:"status"`
+	Optional  bool        `json:"optional,omitempty"`
+	LatencyMs int64       `json:"latencyMs"`
+	Error     string      `json:"error,omitempty"`
+	Details   interface{} `json:"details,omitempty"`
+}
+
+// HealthHandler serves the liveness and readiness probes
+type HealthHandler struct {
+	db      database.Store
+	cfg     *config.Live
+	workers func() map[string]bool
+	client  *http.Client
+}
+
+// NewHealthHandler creates a new health handler; workers reports whether each background
+// worker is running
+func NewHealthHandler(db database.Store, cfg *config.Live, workers func() map[string]bool) *HealthHandler {
+	return &HealthHandler{db: db, cfg: cfg, workers: workers, client: &http.Client{}}
+}
+
+// Healthz reports that the process is alive
+func (h *HealthHandler) Healthz(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": StatusUp})
+}
+
+// Readyz checks the database, its schema, the upstream services and the background workers.
+// It answers 503 when a required dependency is down and 200, possibly degraded, otherwise
+func (h *HealthHandler) Readyz(c *gin.Context) {
+	cfg := h.cfg.Get()
+	checks := map[string]func(ctx context.Context) (interface{}, error){
+		"database": func(ctx context.Context) (interface{}, error) {
+			return nil, h.db.Ping(ctx)
+		},
+		"schema":  h.checkSchema,
+		"workers": h.checkWorkers,
+		"market": func(ctx context.Context) (interface{}, error) {
+			return nil, h.probe(ctx, cfg.Market.BaseURL)
+		},
+		"twitterUserInfo": func(ctx context.Context) (interface{}, error) {
+			return nil, h.probe(ctx, cfg.Twitter.BaseURL)
+		},
+	}
+	optional := map[string]bool{"market": true, "twitterUserInfo": true}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	results := make(map[string]*DependencyStatus, len(checks))
+	for name, check := range checks {
+		wg.Add(1)
+		go func(name string, check func(ctx context.Context) (interface{}, error)) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(c.Request.Context(), cfg.Health.Timeout)
+			defer cancel()
+
+			start := time.Now()
+			details, err := check(ctx)
+			result := &DependencyStatus{
+				Status:    StatusUp,
+				Optional:  optional[name],
+				LatencyMs: time.Since(start).Milliseconds(),
+				Details:   details,
+			}
+			if err != nil {
+				result.Status = StatusDown
+				result.Error = err.Error()
+			}
+
+			mu.Lock()
+			results[name] = result
+			mu.Unlock()
+		}(name, check)
+	}
+	wg.Wait()
+
+	status, code := StatusReady, http.StatusOK
+	for _, result := range results {
+		if result.Status == StatusUp {
+			continue
+		}
+		if !result.Optional {
+			status, code = StatusUnavailable, http.StatusServiceUnavailable
+			break
+		}
+		status = StatusDegraded
+	}
+
+	c.JSON(code, gin.H{
+		"status": status,
+		"checks": results,
+	})
+}
+
+// checkSchema compares the applied schema version with the migrations of this binary
+func (h *HealthHandler) checkSchema(ctx context.Context) (interface{}, error) {
+	migratable, ok := database.Unwrap(h.db).(database.Migratable)
+	if !ok {
+		return nil, nil
+	}
+	migrator, err := migratable.Migrator()
+	if err != nil {
+		return nil, err
+	}
+	current, err := migrator.Current()
+	if err != nil {
+		return nil, err
+	}
+
+	details := gin.H{"version": current, "expected": migrator.Latest()}
+	if current != migrator.Latest() {
+		return details, fmt.Errorf("schema is at version %d, expected %d", current, migrator.Latest())
+	}
+	return details, nil
+}
+
+// checkWorkers fails when a background worker has stopped
+func (h *HealthHandler) checkWorkers(ctx context.Context) (interface{}, error) {
+	workers := h.workers()
+	for name, running := range workers {
+		if !running {
+			return workers, fmt.Errorf("%s is not running", name)
+		}
+	}
+	return workers, nil
+}
+
+// probe checks that an upstream service answers HTTP requests; any response counts, since
+// the base URL itself need not be a valid endpoint
+func (h *HealthHandler) probe(ctx context.Context, baseURL string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, baseURL, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"TwitterMonitor/config"
+	"TwitterMonitor/internal/models"
+	"TwitterMonitor/internal/utils"
+	"crypto/subtle"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// RequestIDHeader carries the request ID, both ways
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength bounds the request IDs accepted from clients
+const maxRequestIDLength = 128
+
+// RequestID tags each request with the ID sent by the client, or a new one, so that every log
+// line of the request carries it; the ID is echoed in the response header
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !validRequestID(id) {
+			id = uuid.NewString()
+		}
+		c.Request = c.Request.WithContext(utils.WithRequestID(c.Request.Context(), id))
+		c.Header(RequestIDHeader, id)
+		c.Next()
+	}
+}
+
+// validRequestID accepts short IDs of printable ASCII characters
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < '!' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// AccessLog logs every request once it has been served
+func AccessLog() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		level := slog.LevelInfo
+		if c.Writer.Status() >= http.StatusInternalServerError {
+			level = slog.LevelWarn
+		}
+		slog.Log(c.Request.Context(), level, "Request served",
+			"method", c.Request.Method,
+			"path", c.Request.URL.Path,
+			"route", c.FullPath(),
+			"status", c.Writer.Status(),
+			"durationMs", time.Since(start).Milliseconds(),
+			"clientIp", c.ClientIP())
+	}
+}
+
+// Recovery answers 500 when a handler panics and logs the panic with the request ID
+func Recovery() gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(nil, func(c *gin.Context, recovered any) {
+		slog.ErrorContext(c.Request.Context(), "Panic serving request", "error", recovered)
+		c.AbortWithStatus(http.StatusInternalServerError)
+	})
+}
+
+// AdminAuth lets through the requests bearing the admin token of the current configuration,
+// so a reload rotates it. Without a token the admin endpoints are disabled
+func AdminAuth(cfg *config.Live) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := cfg.Get().Server.AdminToken
+		if token == "" {
+			c.AbortWithStatusJSON(http.StatusForbidden, models.APIResponse{
+				Error: &models.APIError{Code: "403", Message: "Admin API is disabled; set ADMIN_TOKEN to enable it"},
+			})
+			return
+		}
+
+		if !bearsToken(c, token) {
+			slog.WarnContext(c.Request.Context(), "Rejected admin request", "path", c.Request.URL.Path, "clientIp", c.ClientIP())
+			c.Header("WWW-Authenticate", `Bearer realm="admin"`)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, models.APIResponse{
+				Error: &models.APIError{Code: "401", Message: "Admin token required"},
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// IngestAuth lets through the requests bearing the ingest token of the current configuration,
+// which only the crawler holds. Without a token ingestion is disabled
+func IngestAuth(cfg *config.Live) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		res := respond(c, v1CodeMessage)
+		token := cfg.Get().Server.IngestToken
+		if token == "" {
+			res.fail(http.StatusForbidden, models.ErrCodeForbidden, "Ingestion is disabled; set INGEST_TOKEN to enable it")
+			c.Abort()
+			return
+		}
+
+		if !bearsToken(c, token) {
+			slog.WarnContext(c.Request.Context(), "Rejected ingest request", "path", c.Request.URL.Path, "clientIp", c.ClientIP())
+			c.Header("WWW-Authenticate", `Bearer realm="ingest"`)
+			res.fail(http.StatusUnauthorized, models.ErrCodeUnauthorized, "Ingest token required")
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// bearsToken reports whether the Authorization header of a request is the bearer token
+func bearsToken(c *gin.Context, token string) bool {
+	given, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(given), []byte(token)) == 1
+}
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"TwitterMonitor/config"
+	"TwitterMonitor/internal/database"
+	"TwitterMonitor/internal/models"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// NotificationHandler serves the notifications sent to users
+type NotificationHandler struct {
+	db  database.Store
+	cfg *config.Live
+}
+
+// NewNotificationHandler creates a new notification handler listing cfg.Channels.PageSize
+// notifications by default
+func NewNotificationHandler(db database.Store, cfg *config.Live) *NotificationHandler {
+	return &NotificationHandler{db: db, cfg: cfg}
+}
+
+// GetNotifications lists the notifications of a user, newest first
+func (h *NotificationHandler) GetNotifications(c *gin.Context) {
+	res := respond(c, v1APIResponse)
+	var req models.NotificationListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		res.fail(http.StatusBadRequest, models.ErrCodeInvalidRequest, "Invalid request format: "+err.Error())
+		return
+	}
+
+	// Set default values for limit and offset
+	if req.Limit <= 0 {
+		req.Limit = h.cfg.Get().Channels.PageSize
+	}
+	if req.Offset < 0 {
+		req.Offset = 0
+	}
+
+	notifications, err := h.db.GetNotifications(c.Request.Context(), req.UserID, req.Limit, req.Offset)
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Failed to get notifications", "error", err)
+		res.failErr(err, http.StatusInternalServerError, "Failed to get notifications")
+		return
+	}
+	if notifications == nil {
+		notifications = []*models.Notification{}
+	}
+
+	res.ok(gin.H{
+		"notifications": notifications,
+	})
+}
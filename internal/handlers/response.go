@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"TwitterMonitor/internal/models"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// apiVersionKey holds the API version of the route group serving a request
+const apiVersionKey = "apiVersion"
+
+// APIVersion tags the requests of a route group with the API version whose response format
+// they are answered in. /v2 answers every endpoint with models.APIResponse and the error codes
+// of the models.ErrCode catalog; /v1 keeps the format each endpoint always had
+func APIVersion(version int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(apiVersionKey, version)
+		c.Next()
+	}
+}
+
+// isV2 reports whether a request is answered in the /v2 format
+func isV2(c *gin.Context) bool {
+	return c.GetInt(apiVersionKey) >= 2
+}
+
+// v1Format is the response format of an endpoint in /v1
+type v1Format int
+
+const (
+	// v1CodeMessage answers {"code": 10000, "message": "success", "data": ...}, with the HTTP
+	// status as the code of errors
+	v1CodeMessage v1Format = iota
+	// v1APIResponse answers models.APIResponse with the HTTP status as the error code
+	v1APIResponse
+)
+
+// responder answers one request in the format of its API version
+type responder struct {
+	c      *gin.Context
+	format v1Format
+}
+
+// respond returns the responder of an endpoint answering /v1 requests in format
+func respond(c *gin.Context, format v1Format) *responder {
+	return &responder{c: c, format: format}
+}
+
+// ok answers data, which may be nil
+func (r *responder) ok(data interface{}) {
+	if isV2(r.c) || r.format == v1APIResponse {
+		r.c.JSON(http.StatusOK, models.APIResponse{Success: true, Data: data})
+		return
+	}
+	body := gin.H{"code": 10000, "message": "success"}
+	if data != nil {
+		body["data"] = data
+	}
+	r.c.JSON(http.StatusOK, body)
+}
+
+// fail answers an error with one of the models.ErrCode codes. /v2 takes the status from the
+// code, /v1 answers v1Status as it always has
+func (r *responder) fail(v1Status int, code, message string) {
+	if isV2(r.c) {
+		r.c.JSON(errorStatuses[code], models.APIResponse{
+			Error: &models.APIError{Code: code, Message: message},
+		})
+		return
+	}
+	r.v1Error(v1Status, v1Status, message)
+}
+
+// failErr answers an operation that failed with err, as a timeout, a cancellation or
+// otherwise with the status fallback
+func (r *responder) failErr(err error, fallback int, message string) {
+	status := errorStatus(r.c, err, fallback)
+	r.fail(status, statusErrorCodes[status], message)
+}
+
+// v1Error answers an error in the /v1 format of the endpoint
+func (r *responder) v1Error(status, code int, message string) {
+	if r.format == v1APIResponse {
+		r.c.JSON(status, models.APIResponse{
+			Error: &models.APIError{Code: strconv.Itoa(code), Message: message},
+		})
+		return
+	}
+	r.c.JSON(status, gin.H{"code": code, "message": message})
+}
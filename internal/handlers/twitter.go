@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"TwitterMonitor/internal/database"
+	"TwitterMonitor/internal/extractor"
+	"TwitterMonitor/internal/metrics"
+	"TwitterMonitor/internal/models"
+	"TwitterMonitor/internal/risk"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TwitterHandler handles ingestion of Twitter info records and account risk lookups
+type TwitterHandler struct {
+	db     database.Store
+	scorer *risk.Scorer
+}
+
+// NewTwitterHandler creates a new Twitter handler
+func NewTwitterHandler(db database.Store) *TwitterHandler {
+	return &TwitterHandler{db: db, scorer: risk.NewScorer(db)}
+}
+
+// IngestTwitterInfo stores tweets and profile updates collected by the crawler,
+// extracting the contract addresses and cashtags mentioned in their content
+func (h *TwitterHandler) IngestTwitterInfo(c *gin.Context) {
+	res := respond(c, v1CodeMessage)
+	var req models.IngestTwitterInfoRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		slog.ErrorContext(c.Request.Context(), "Error parsing request", "error", err)
+		res.fail(http.StatusBadRequest, models.ErrCodeInvalidRequest, "Invalid request parameters")
+		return
+	}
+
+	for _, info := range req.Items {
+		if info.TweetsId == "" || info.TwitterId == "" {
+			res.fail(http.StatusBadRequest, models.ErrCodeInvalidRequest, "tweetsId and twitterId are required")
+			return
+		}
+		if info.Type != models.TwitterInfoTypeTweet && info.Type != models.TwitterInfoTypeUpdate {
+			res.fail(http.StatusBadRequest, models.ErrCodeInvalidRequest, "Invalid type")
+			return
+		}
+	}
+
+	for i := range req.Items {
+		info := &req.Items[i]
+		extractor.Apply(info)
+
+		if err := h.db.InsertTwitterInfo(c.Request.Context(), info); err != nil {
+			slog.ErrorContext(c.Request.Context(), "Error ingesting Twitter info", "error", err)
+			res.failErr(err, http.StatusInternalServerError, "Failed to ingest Twitter info")
+			return
+		}
+		metrics.TwitterInfoIngested(info.Type)
+	}
+
+	res.ok(gin.H{
+		"items": req.Items,
+	})
+}
+
+// DeleteTwitterInfo marks tweets the crawler saw being deleted by their author
+func (h *TwitterHandler) DeleteTwitterInfo(c *gin.Context) {
+	res := respond(c, v1CodeMessage)
+	var req models.DeleteTwitterInfoRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		slog.ErrorContext(c.Request.Context(), "Error parsing request", "error", err)
+		res.fail(http.StatusBadRequest, models.ErrCodeInvalidRequest, "Invalid request parameters")
+		return
+	}
+
+	if err := h.db.MarkTwitterInfoDeleted(c.Request.Context(), req.TweetsIds, time.Now().UnixMilli()); err != nil {
+		slog.ErrorContext(c.Request.Context(), "Error marking Twitter info deleted", "error", err)
+		res.failErr(err, http.StatusInternalServerError, "Failed to mark Twitter info deleted")
+		return
+	}
+
+	res.ok(nil)
+}
+
+// GetRisk computes the risk score of a Twitter account and returns it with its contributing factors
+func (h *TwitterHandler) GetRisk(c *gin.Context) {
+	res := respond(c, v1APIResponse)
+	twitterId := c.Query("twitterId")
+	if twitterId == "" {
+		res.fail(http.StatusBadRequest, models.ErrCodeInvalidRequest, "twitterId parameter is required")
+		return
+	}
+
+	score, err := h.scorer.Score(c.Request.Context(), twitterId)
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Failed to score Twitter account", "error", err)
+		res.failErr(err, http.StatusInternalServerError, "Failed to compute risk score")
+		return
+	}
+
+	res.ok(score)
+}
@@ -0,0 +1,138 @@
+package jobs
+
+import (
+	"TwitterMonitor/internal/database"
+	"TwitterMonitor/internal/models"
+	"TwitterMonitor/internal/risk"
+	"TwitterMonitor/internal/twitter"
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+)
+
+const (
+	// accountRefreshInterval is how often the refresher looks for stale accounts
+	accountRefreshInterval = 10 * time.Minute
+	// accountStaleAfter is how old cached account metadata may get before it is refreshed
+	accountStaleAfter = time.Hour
+	// accountRefreshBatch caps the upstream lookups made per run
+	accountRefreshBatch = 100
+)
+
+// AccountRefresher keeps the twitter_accounts cache of every watched account up to date
+type AccountRefresher struct {
+	db     database.Store
+	client *twitter.Client
+	scorer *risk.Scorer
+}
+
+// NewAccountRefresher creates a new account refresher
+func NewAccountRefresher(db database.Store, client *twitter.Client) *AccountRefresher {
+	return &AccountRefresher{db: db, client: client, scorer: risk.NewScorer(db)}
+}
+
+// Run refreshes accounts every accountRefreshInterval until ctx is cancelled
+func (r *AccountRefresher) Run(ctx context.Context) {
+	ticker := time.NewTicker(accountRefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := r.RefreshOnce(ctx); err != nil && ctx.Err() == nil {
+			slog.Error("Failed to refresh Twitter accounts", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RefreshOnce refreshes the metadata and risk score of watched accounts that are missing from
+// the cache or stale. It stops between accounts once ctx is cancelled
+func (r *AccountRefresher) RefreshOnce(ctx context.Context) error {
+	twitterIds, err := r.db.GetWatchedTwitterIds(ctx)
+	if err != nil {
+		return err
+	}
+
+	accounts, err := r.db.GetTwitterAccounts(ctx, twitterIds)
+	if err != nil {
+		return err
+	}
+
+	staleBefore := time.Now().Add(-accountStaleAfter).UnixMilli()
+	refreshed := 0
+	for _, twitterId := range twitterIds {
+		if refreshed >= accountRefreshBatch || ctx.Err() != nil {
+			break
+		}
+
+		var name string
+		if account, ok := accounts[twitterId]; ok {
+			if account.RefreshedAt > staleBefore {
+				continue
+			}
+			name = account.UserName
+		} else if name, err = r.watchedName(ctx, twitterId); err != nil {
+			return err
+		}
+
+		if err := r.refresh(ctx, twitterId, name); err != nil && ctx.Err() == nil {
+			slog.Error("Failed to refresh Twitter account", "twitterId", twitterId, "error", err)
+		}
+		refreshed++
+	}
+
+	if refreshed > 0 {
+		slog.Info("Refreshed Twitter accounts", "count", refreshed)
+	}
+	return nil
+}
+
+// watchedName returns the name a channel watches an account under, for accounts never cached
+func (r *AccountRefresher) watchedName(ctx context.Context, twitterId string) (string, error) {
+	channels, err := r.db.GetChannelsWatching(ctx, twitterId)
+	if err != nil {
+		return "", err
+	}
+	for _, channel := range channels {
+		for _, watch := range channel.Watchlist {
+			if watch.TwitterId == twitterId && watch.TwitterName != "" {
+				return watch.TwitterName, nil
+			}
+		}
+	}
+	return "", nil
+}
+
+// refresh looks an account up by its last known name, falling back to its ID, and caches the result
+func (r *AccountRefresher) refresh(ctx context.Context, twitterId, name string) error {
+	var user *models.TwitterUser
+	var err error
+	if name != "" {
+		user, err = r.client.LookupUser(ctx, name)
+	}
+	if name == "" || errors.Is(err, twitter.ErrUserNotFound) || (err == nil && user.ID != twitterId) {
+		// The account was renamed since it was last seen
+		user, err = r.client.LookupUser(ctx, twitterId)
+	}
+	if err != nil {
+		return err
+	}
+	if user.ID != twitterId {
+		return twitter.ErrUserNotFound
+	}
+
+	now := time.Now().UnixMilli()
+	if err := r.db.RecordTwitterHandle(ctx, user.ID, user.UserName, now); err != nil {
+		return err
+	}
+	if err := r.db.UpsertTwitterAccount(ctx, twitter.AccountFromUser(user, now)); err != nil {
+		return err
+	}
+	_, err = r.scorer.Score(ctx, twitterId)
+	return err
+}
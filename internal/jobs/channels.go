@@ -0,0 +1,50 @@
+package jobs
+
+import (
+	"TwitterMonitor/config"
+	"TwitterMonitor/internal/database"
+	"context"
+	"log/slog"
+	"time"
+)
+
+// channelPurgeInterval is how often deleted channels past their grace period are purged
+const channelPurgeInterval = time.Hour
+
+// ChannelPurger hard-deletes channels, with their follows, once they have been soft-deleted
+// for longer than the restore grace period
+type ChannelPurger struct {
+	db  database.Store
+	cfg *config.Live
+}
+
+// NewChannelPurger creates a new channel purger using the current grace period of cfg
+func NewChannelPurger(db database.Store, cfg *config.Live) *ChannelPurger {
+	return &ChannelPurger{db: db, cfg: cfg}
+}
+
+// Run purges channels every channelPurgeInterval until ctx is cancelled
+func (p *ChannelPurger) Run(ctx context.Context) {
+	ticker := time.NewTicker(channelPurgeInterval)
+	defer ticker.Stop()
+
+	for {
+		purged, err := p.PurgeOnce(ctx)
+		if err != nil && ctx.Err() == nil {
+			slog.Error("Failed to purge deleted channels", "error", err)
+		} else if purged > 0 {
+			slog.Info("Purged deleted channels", "count", purged)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// PurgeOnce removes the channels whose grace period has ended and returns how many were removed
+func (p *ChannelPurger) PurgeOnce(ctx context.Context) (int, error) {
+	return p.db.PurgeDeletedChannels(ctx, time.Now().Add(-p.cfg.Get().Channels.RestoreGrace()).UnixMilli())
+}
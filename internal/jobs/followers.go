@@ -0,0 +1,116 @@
+package jobs
+
+import (
+	"TwitterMonitor/internal/database"
+	"context"
+	"log/slog"
+	"slices"
+	"time"
+)
+
+const (
+	// followerReconcileInterval is how often follower counts are checked against the follows table
+	followerReconcileInterval = time.Hour
+	// followerReconcilePage is how many channels are loaded at a time
+	followerReconcilePage = 100
+)
+
+// FollowerDiscrepancy describes a channel whose stored follower data differs from its follows
+type FollowerDiscrepancy struct {
+	ChannelID    string `json:"channelId"`
+	StoredCount  int    `json:"storedCount"`
+	ActualCount  int    `json:"actualCount"`
+	StoredRecent []int  `json:"storedRecent"`
+	ActualRecent []int  `json:"actualRecent"`
+	Fixed        bool   `json:"fixed"`
+}
+
+// FollowerReconciler recomputes the denormalized followerCount and recentFollowers of every
+// channel from the follows table, reporting and optionally fixing the channels that drifted
+type FollowerReconciler struct {
+	db              database.Store
+	fix             bool
+	recentFollowers int
+}
+
+// NewFollowerReconciler creates a new follower reconciler for a store keeping recentFollowers
+// followers per channel; with fix set, drifted channels are repaired
+func NewFollowerReconciler(db database.Store, fix bool, recentFollowers int) *FollowerReconciler {
+	return &FollowerReconciler{db: db, fix: fix, recentFollowers: recentFollowers}
+}
+
+// Run reconciles every followerReconcileInterval until ctx is cancelled
+func (r *FollowerReconciler) Run(ctx context.Context) {
+	ticker := time.NewTicker(followerReconcileInterval)
+	defer ticker.Stop()
+
+	for {
+		discrepancies, err := r.ReconcileOnce(ctx)
+		if err != nil && ctx.Err() == nil {
+			slog.Error("Failed to reconcile follower counts", "error", err)
+		}
+		for _, d := range discrepancies {
+			slog.Warn("Follower data drifted", "channelId", d.ChannelID,
+				"storedCount", d.StoredCount, "actualCount", d.ActualCount, "fixed", d.Fixed)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ReconcileOnce compares every channel's followerCount and recentFollowers with its follows and
+// returns the channels that differ, repairing them if the reconciler was created with fix.
+// It stops between pages once ctx is cancelled
+func (r *FollowerReconciler) ReconcileOnce(ctx context.Context) ([]FollowerDiscrepancy, error) {
+	var discrepancies []FollowerDiscrepancy
+	for offset := 0; ; offset += followerReconcilePage {
+		if err := ctx.Err(); err != nil {
+			return discrepancies, err
+		}
+		channels, err := r.db.GetAllChannels(ctx, followerReconcilePage, offset)
+		if err != nil {
+			return discrepancies, err
+		}
+
+		for _, channel := range channels {
+			count, err := r.db.CountFollowers(ctx, channel.ID)
+			if err != nil {
+				return discrepancies, err
+			}
+			recent, err := r.db.GetRecentFollowers(ctx, channel.ID, r.recentFollowers)
+			if err != nil {
+				return discrepancies, err
+			}
+			if recent == nil {
+				recent = []int{}
+			}
+			if count == channel.FollowerCount && slices.Equal(recent, channel.RecentFollowers) {
+				continue
+			}
+
+			discrepancy := FollowerDiscrepancy{
+				ChannelID:    channel.ID,
+				StoredCount:  channel.FollowerCount,
+				ActualCount:  count,
+				StoredRecent: channel.RecentFollowers,
+				ActualRecent: recent,
+			}
+			if r.fix {
+				if err := r.db.RepairFollowerStats(ctx, channel.ID); err != nil {
+					slog.Error("Failed to repair follower data", "channelId", channel.ID, "error", err)
+				} else {
+					discrepancy.Fixed = true
+				}
+			}
+			discrepancies = append(discrepancies, discrepancy)
+		}
+
+		if len(channels) < followerReconcilePage {
+			return discrepancies, nil
+		}
+	}
+}
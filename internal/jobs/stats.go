@@ -0,0 +1,54 @@
+package jobs
+
+import (
+	"TwitterMonitor/internal/database"
+	"TwitterMonitor/internal/metrics"
+	"context"
+	"log/slog"
+	"time"
+)
+
+// statsInterval is how often the business totals are recounted
+const statsInterval = time.Minute
+
+// StatsCollector keeps the channel and follow gauges up to date
+type StatsCollector struct {
+	db database.Store
+}
+
+// NewStatsCollector creates a new stats collector
+func NewStatsCollector(db database.Store) *StatsCollector {
+	return &StatsCollector{db: db}
+}
+
+// Run recounts the totals every statsInterval until ctx is cancelled
+func (s *StatsCollector) Run(ctx context.Context) {
+	ticker := time.NewTicker(statsInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.CollectOnce(ctx); err != nil && ctx.Err() == nil {
+			slog.Error("Failed to collect business metrics", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// CollectOnce counts the live channels and their follows
+func (s *StatsCollector) CollectOnce(ctx context.Context) error {
+	channels, err := s.db.CountChannels(ctx)
+	if err != nil {
+		return err
+	}
+	follows, err := s.db.CountFollows(ctx)
+	if err != nil {
+		return err
+	}
+	metrics.SetBusinessTotals(channels, follows)
+	return nil
+}
@@ -0,0 +1,54 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Middleware records the count and latency of every request, labelled with the route pattern
+// rather than the path so that IDs do not create new series
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		method := c.Request.Method
+		httpRequests.WithLabelValues(method, route, strconv.Itoa(c.Writer.Status())).Inc()
+		httpDuration.WithLabelValues(method, route).Observe(time.Since(start).Seconds())
+	}
+}
+
+// transport records the latency and outcome of requests to an upstream service
+type transport struct {
+	upstream string
+	next     http.RoundTripper
+}
+
+// Transport instruments the requests sent through next as calls to the named upstream;
+// a nil next uses http.DefaultTransport
+func Transport(upstream string, next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &transport{upstream: upstream, next: next}
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	upstreamDuration.WithLabelValues(t.upstream).Observe(time.Since(start).Seconds())
+
+	outcome := "error"
+	if err == nil {
+		outcome = strconv.Itoa(resp.StatusCode/100) + "xx"
+	}
+	upstreamRequests.WithLabelValues(t.upstream, outcome).Inc()
+	return resp, err
+}
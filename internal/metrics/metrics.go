@@ -0,0 +1,89 @@
+package metrics
+
+import (
+	"TwitterMonitor/internal/models"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// namespace prefixes every metric of the service
+const namespace = "twitter_monitor"
+
+var (
+	httpRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by route and status code.",
+	}, []string{"method", "route", "status"})
+
+	httpDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by route.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	dbDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "db_query_duration_seconds",
+		Help:      "Duration of store methods by method and outcome (ok, not_found or error).",
+		Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"method", "outcome"})
+
+	upstreamRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "upstream_requests_total",
+		Help:      "Requests to upstream services by outcome (the status code class, or error when no response arrived).",
+	}, []string{"upstream", "outcome"})
+
+	upstreamDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "upstream_request_duration_seconds",
+		Help:      "Latency of requests to upstream services.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"upstream"})
+
+	channels = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "channels",
+		Help:      "Channels that have not been deleted.",
+	})
+
+	follows = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "follows",
+		Help:      "Follows of channels that have not been deleted.",
+	})
+
+	twitterInfoIngested = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "twitter_info_ingested_total",
+		Help:      "twitter_info rows ingested by type; rate() over 1m gives rows per minute.",
+	}, []string{"type"})
+)
+
+// Handler serves the metrics in the Prometheus text format
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// SetBusinessTotals updates the channel and follow gauges
+func SetBusinessTotals(channelCount, followCount int) {
+	channels.Set(float64(channelCount))
+	follows.Set(float64(followCount))
+}
+
+// TwitterInfoIngested counts an ingested twitter_info row of the given type
+func TwitterInfoIngested(infoType int) {
+	label := "other"
+	switch infoType {
+	case models.TwitterInfoTypeTweet:
+		label = "tweet"
+	case models.TwitterInfoTypeUpdate:
+		label = "update"
+	}
+	twitterInfoIngested.WithLabelValues(label).Inc()
+}
@@ -0,0 +1,73 @@
+package metrics
+
+import (
+	"TwitterMonitor/internal/database"
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// InstrumentStore records the duration and outcome of every method of db, and exports the
+// connection pool statistics of stores backed by a sql.DB
+func InstrumentStore(db database.Store) database.Store {
+	if pooled, ok := database.Unwrap(db).(database.Pooled); ok {
+		prometheus.MustRegister(&poolCollector{pooled: pooled})
+	}
+	return database.WithHook(db, observeStore)
+}
+
+// observeStore records the duration of a store operation by method and outcome
+func observeStore(ctx context.Context, op string, write bool, call func(context.Context) error) error {
+	start := time.Now()
+	err := call(ctx)
+	outcome := "ok"
+	if errors.Is(err, sql.ErrNoRows) {
+		outcome = "not_found"
+	} else if err != nil {
+		outcome = "error"
+	}
+	dbDuration.WithLabelValues(op, outcome).Observe(time.Since(start).Seconds())
+	return err
+}
+
+var (
+	poolOpen = prometheus.NewDesc(namespace+"_db_connections_open",
+		"Established connections, in use or idle.", nil, nil)
+	poolInUse = prometheus.NewDesc(namespace+"_db_connections_in_use",
+		"Connections currently in use.", nil, nil)
+	poolIdle = prometheus.NewDesc(namespace+"_db_connections_idle",
+		"Idle connections.", nil, nil)
+	poolMaxOpen = prometheus.NewDesc(namespace+"_db_connections_max_open",
+		"Maximum number of open connections, 0 for unlimited.", nil, nil)
+	poolWaits = prometheus.NewDesc(namespace+"_db_connection_waits_total",
+		"Times a query waited for a free connection.", nil, nil)
+	poolWaitDuration = prometheus.NewDesc(namespace+"_db_connection_wait_seconds_total",
+		"Time spent waiting for a free connection.", nil, nil)
+)
+
+// poolCollector exports sql.DB.Stats() at scrape time
+type poolCollector struct {
+	pooled database.Pooled
+}
+
+func (c *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- poolOpen
+	ch <- poolInUse
+	ch <- poolIdle
+	ch <- poolMaxOpen
+	ch <- poolWaits
+	ch <- poolWaitDuration
+}
+
+func (c *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.pooled.PoolStats()
+	ch <- prometheus.MustNewConstMetric(poolOpen, prometheus.GaugeValue, float64(stats.OpenConnections))
+	ch <- prometheus.MustNewConstMetric(poolInUse, prometheus.GaugeValue, float64(stats.InUse))
+	ch <- prometheus.MustNewConstMetric(poolIdle, prometheus.GaugeValue, float64(stats.Idle))
+	ch <- prometheus.MustNewConstMetric(poolMaxOpen, prometheus.GaugeValue, float64(stats.MaxOpenConnections))
+	ch <- prometheus.MustNewConstMetric(poolWaits, prometheus.CounterValue, float64(stats.WaitCount))
+	ch <- prometheus.MustNewConstMetric(poolWaitDuration, prometheus.CounterValue, stats.WaitDuration.Seconds())
+}
@@ -7,22 +7,6 @@ const (
 	ChannelTypeTwitter ChannelType = "X_TWITTER"
 )
 
-// AccountConfig represents the configuration for a monitored Twitter account
-type AccountConfig struct {
-	ShowTweets         bool `json:"showTweets"`
-	ShowProfileUpdates bool `json:"showProfileUpdates"`
-	ShowFollows        bool `json:"showFollows"`
-	FilterCA           bool `json:"filterCA"`
-}
-
-// MonitoredAccount represents a Twitter account being monitored
-type MonitoredAccount struct {
-	Username    string        `json:"username"`
-	DisplayName string        `json:"displayName"`
-	IsVerified  bool          `json:"isVerified"`
-	Config      AccountConfig `json:"config"`
-}
-
 // Channel represents a Twitter monitoring channel
 type Channel struct {
 	ID              string      `json:"id" gorm:"primaryKey"`
@@ -37,10 +21,11 @@ type Channel struct {
 	HotExpireAt     string      `json:"hotExpireAt"`
 	CreatedAt       int64       `json:"createdAt"`
 	UpdatedAt       int64       `json:"updatedAt"`
-	Watchlist       []Watchlist `json:"watchlist" gorm:"type:jsonb"`
-	Eventlist       []EventList `json:"eventlist" gorm:"type:jsonb"`
-	FollowerCount   string      `json:"followerCount"`
-	RecentFollowers []int       `json:"recentFollowers" gorm:"type:jsonb"`
+	Watchlist       []Watchlist `json:"watchlist" gorm:"type:jsonb;serializer:json"`
+	Eventlist       []EventList `json:"eventlist" gorm:"type:jsonb;serializer:json"`
+	FollowerCount   int         `json:"followerCount,string" gorm:"not null;default:0"`
+	RecentFollowers []int       `json:"recentFollowers" gorm:"type:jsonb;serializer:json"`
+	DeletedAt       int64       `json:"deletedAt,omitempty"`
 }
 
 // Watchlist represents a watched address in a channel
@@ -51,11 +36,92 @@ type Watchlist struct {
 	TwitterRisk   string `json:"twitterRisk"`
 	TwitterAvatar string `json:"twitterAvatar"`
 
-	Tweets        bool   `json:"tweets"`
-	ProfileUpdate bool   `json:"profileUpdate"`
-	Follows       bool   `json:"follows"`
-	CA            string `json:"ca"` // CA address
-	Risk          int    `json:"risk"`
+	Tweets        bool         `json:"tweets"`
+	ProfileUpdate bool         `json:"profileUpdate"`
+	Follows       bool         `json:"follows"`
+	FilterCA      CAFilterMode `json:"filterCA"`
+	CA            string       `json:"ca"`  // CA address
+	CAs           []string     `json:"cas"` // additional CA addresses for CAFilterList
+	Risk          int          `json:"risk"`
+}
+
+// CAFilterMode selects which content of a watched account is shown by its CA mentions
+type CAFilterMode string
+
+const (
+	CAFilterAll  CAFilterMode = "all"  // all tweets and activity
+	CAFilterAny  CAFilterMode = "any"  // only content mentioning any CA
+	CAFilterList CAFilterMode = "list" // only content mentioning one of the listed CAs
+)
+
+// CAFilter returns the effective CA filter mode and CA list of a watched account.
+// Entries without filterCA keep the old meaning of ca: only content mentioning that CA
+func (w Watchlist) CAFilter() (CAFilterMode, []string) {
+	var cas []string
+	seen := make(map[string]bool)
+	for _, ca := range append([]string{w.CA}, w.CAs...) {
+		if ca == "" || seen[ca] {
+			continue
+		}
+		seen[ca] = true
+		cas = append(cas, ca)
+	}
+
+	mode := w.FilterCA
+	if mode == "" {
+		mode = CAFilterAll
+		if len(cas) > 0 {
+			mode = CAFilterList
+		}
+	}
+	return mode, cas
+}
+
+// ChannelWatch is a watchlist entry of a channel, stored in channel_watch so channels can be
+// looked up by the Twitter account they watch
+type ChannelWatch struct {
+	ChannelID     string       `json:"channelId" gorm:"primaryKey"`
+	Position      int          `json:"position" gorm:"primaryKey"`
+	TwitterId     string       `json:"twitterId" gorm:"index"`
+	Tweets        bool         `json:"tweets"`
+	ProfileUpdate bool         `json:"profileUpdate"`
+	Follows       bool         `json:"follows"`
+	FilterCA      CAFilterMode `json:"filterCA"`
+	CA            string       `json:"ca"`
+	CAs           []string     `json:"cas" gorm:"column:cas;type:jsonb;serializer:json"`
+}
+
+// TableName keeps the table name used by the SQL schema
+func (ChannelWatch) TableName() string {
+	return "channel_watch"
+}
+
+// ChannelWatchesFromWatchlist converts a channel's watchlist to channel_watch rows, skipping
+// entries that have not been resolved to a Twitter ID
+func ChannelWatchesFromWatchlist(channelID string, watchlist []Watchlist) []ChannelWatch {
+	var watches []ChannelWatch
+	for i, watch := range watchlist {
+		if watch.TwitterId == "" {
+			continue
+		}
+		watches = append(watches, ChannelWatch{
+			ChannelID:     channelID,
+			Position:      i,
+			TwitterId:     watch.TwitterId,
+			Tweets:        watch.Tweets,
+			ProfileUpdate: watch.ProfileUpdate,
+			Follows:       watch.Follows,
+			FilterCA:      watch.FilterCA,
+			CA:            watch.CA,
+			CAs:           watch.CAs,
+		})
+	}
+	return watches
+}
+
+// CAFilter returns the effective CA filter mode and CA list of the watch, as Watchlist.CAFilter
+func (w ChannelWatch) CAFilter() (CAFilterMode, []string) {
+	return Watchlist{FilterCA: w.FilterCA, CA: w.CA, CAs: w.CAs}.CAFilter()
 }
 
 // EventList represents an event filter in a channel
@@ -163,6 +229,26 @@ type DeleteChannelRequest struct {
 	UserID int    `json:"userId" binding:"required"`
 }
 
+// RestoreChannelRequest represents the request to restore a deleted channel
+type RestoreChannelRequest struct {
+	ID     string `json:"id" binding:"required"`
+	UserID int    `json:"userId" binding:"required"`
+}
+
+// ChannelHistoryRequest represents the request to get the version history of a channel
+type ChannelHistoryRequest struct {
+	ChannelID string `form:"channelId" binding:"required"`
+	Offset    int    `form:"offset"`
+	Limit     int    `form:"limit"`
+}
+
+// RollbackChannelRequest represents the request to restore a channel to one of its versions
+type RollbackChannelRequest struct {
+	ID      string `json:"id" binding:"required"`
+	UserID  int    `json:"userId" binding:"required"`
+	Version int    `json:"version" binding:"required"`
+}
+
 // ChannelListRequest represents the request to get channel list
 type ChannelListRequest struct {
 	UserID int    `form:"userId"`
@@ -192,15 +278,106 @@ type Follow struct {
 	CreatedAt int64  `json:"createdAt"`
 }
 
+// Notification types
+const (
+	NotificationChannelDeleted  = "channel_deleted"  // a followed channel was deleted by its owner
+	NotificationChannelRestored = "channel_restored" // a followed channel was restored by its owner
+)
+
+// Notification represents a notice sent to a user about a channel they follow
+type Notification struct {
+	ID          int64  `json:"id" gorm:"primaryKey;autoIncrement"`
+	UserID      int    `json:"userId"`
+	Type        string `json:"type"`
+	ChannelID   string `json:"channelId"`
+	ChannelName string `json:"channelName"`
+	CreatedAt   int64  `json:"createdAt"`
+}
+
+// NotificationListRequest represents the request to get a user's notifications
+type NotificationListRequest struct {
+	UserID int `form:"userId" binding:"required"`
+	Offset int `form:"offset"`
+	Limit  int `form:"limit"`
+}
+
+// Twitter info record types
+const (
+	TwitterInfoTypeTweet  = 1 // content is a tweet
+	TwitterInfoTypeUpdate = 2 // content is profile update / follow data
+)
+
 // TwitterInfo represents a Twitter information record
 type TwitterInfo struct {
-	ID         int    `json:"id" gorm:"primaryKey;autoIncrement"`
-	TwitterId  string `json:"twitterId" gorm:"not null"`
-	Content    string `json:"content" gorm:"type:longtext"`
-	ChainId    string `json:"chainId"`
-	Address    string `json:"address"`
-	CreateTime int64  `json:"createTime"`
-	Type       int    `json:"type" gorm:"not null"`
+	ID         int               `json:"id" gorm:"primaryKey;autoIncrement"`
+	TweetsId   string            `json:"tweetsId" gorm:"uniqueIndex;not null"`
+	TwitterId  string            `json:"twitterId" gorm:"not null"`
+	Content    string            `json:"content" gorm:"type:text"`
+	ChainId    string            `json:"chainId"`
+	Address    string            `json:"address"`
+	Cashtags   string            `json:"cashtags"`
+	CreateTime int64             `json:"createTime"`
+	Type       int               `json:"type" gorm:"not null"`
+	DeletedAt  int64             `json:"deletedAt,omitempty"`
+	Addresses  []ContractAddress `json:"addresses,omitempty" gorm:"-"`
+}
+
+// TableName keeps the table name used by the SQL schema
+func (TwitterInfo) TableName() string {
+	return "twitter_info"
+}
+
+// ContractAddress represents a token contract address mentioned in a Twitter info record
+type ContractAddress struct {
+	ChainId string `json:"chainId"`
+	Address string `json:"address"`
+}
+
+// IngestTwitterInfoRequest represents the request to ingest Twitter info records
+type IngestTwitterInfoRequest struct {
+	Items []TwitterInfo `json:"items" binding:"required"`
+}
+
+// DeleteTwitterInfoRequest represents the request to mark tweets as deleted by their author
+type DeleteTwitterInfoRequest struct {
+	TweetsIds []string `json:"tweetsIds" binding:"required"`
+}
+
+// TweetStats summarizes the stored tweets of a Twitter account
+type TweetStats struct {
+	Total      int `json:"total"`
+	WithCA     int `json:"withCA"`
+	DistinctCA int `json:"distinctCA"`
+	Deleted    int `json:"deleted"`
+}
+
+// Risk levels of a watched Twitter account
+const (
+	RiskLevelLow    = "low"
+	RiskLevelMedium = "medium"
+	RiskLevelHigh   = "high"
+)
+
+// RiskScore represents the computed risk of a watched Twitter account
+type RiskScore struct {
+	TwitterId string       `json:"twitterId" gorm:"primaryKey"`
+	Score     int          `json:"score"`
+	Level     string       `json:"level"`
+	Factors   []RiskFactor `json:"factors" gorm:"type:jsonb;serializer:json"`
+	UpdatedAt int64        `json:"updatedAt"`
+}
+
+// TableName keeps the table name used by the SQL schema
+func (RiskScore) TableName() string {
+	return "account_risk"
+}
+
+// RiskFactor represents one signal contributing to a risk score
+type RiskFactor struct {
+	Name   string  `json:"name"`
+	Value  float64 `json:"value"`
+	Points int     `json:"points"`
+	Detail string  `json:"detail"`
 }
 
 // ChannelContentRequest represents the request to get channel content
@@ -211,3 +388,35 @@ type ChannelContentRequest struct {
 	Type        string `form:"type"`
 	ContentType int    `form:"contentType" binding:"required"`
 }
+
+// TwitterUser represents a Twitter account as returned by the user-info service
+type TwitterUser struct {
+	ID             string `json:"id"`
+	UserName       string `json:"userName"`
+	DisplayName    string `json:"displayName"`
+	Avatar         string `json:"avatar"`
+	Verified       bool   `json:"verified"`
+	FollowersCount int    `json:"followersCount"`
+	FollowingCount int    `json:"followingCount"`
+	CreatedAt      string `json:"createdAt"`
+}
+
+// TwitterHandle represents a username a Twitter account has been seen with
+type TwitterHandle struct {
+	TwitterId   string `json:"twitterId" gorm:"primaryKey"`
+	UserName    string `json:"userName" gorm:"primaryKey"`
+	FirstSeenAt int64  `json:"firstSeenAt"`
+	LastSeenAt  int64  `json:"lastSeenAt"`
+}
+
+// TwitterAccount represents the cached metadata of a watched Twitter account
+type TwitterAccount struct {
+	TwitterId      string `json:"twitterId" gorm:"primaryKey"`
+	UserName       string `json:"userName"`
+	DisplayName    string `json:"displayName"`
+	Avatar         string `json:"avatar"`
+	Verified       bool   `json:"verified"`
+	FollowersCount int    `json:"followersCount"`
+	FollowingCount int    `json:"followingCount"`
+	RefreshedAt    int64  `json:"refreshedAt"`
+}
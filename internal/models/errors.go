@@ -0,0 +1,36 @@
+package models
+
+// Error codes of APIError in the /v2 API. They are stable: clients may branch on them, so a
+// code is never renamed or reused for another failure
+//
+// There is no ALREADY_FOLLOWING: following a channel is idempotent, in /v2 as in /v1, and
+// following it again succeeds with alreadyFollowing set in the response data
+const (
+	// ErrCodeInvalidRequest reports a malformed request or a missing or invalid parameter
+	ErrCodeInvalidRequest = "INVALID_REQUEST"
+	// ErrCodeUnauthorized reports a request without the credentials the endpoint requires
+	ErrCodeUnauthorized = "UNAUTHORIZED"
+	// ErrCodeForbidden reports a request to an endpoint that is disabled or not open to the caller
+	ErrCodeForbidden = "FORBIDDEN"
+	// ErrCodeUnknownTwitterUser reports a watchlist entry that matches no Twitter account
+	ErrCodeUnknownTwitterUser = "UNKNOWN_TWITTER_USER"
+	// ErrCodeQuotaExceeded reports a request going over a per-user limit, like the one channel
+	// a user may own or the watchlist size
+	ErrCodeQuotaExceeded = "QUOTA_EXCEEDED"
+	// ErrCodeNotChannelOwner reports a change to a channel by someone other than its owner
+	ErrCodeNotChannelOwner = "NOT_CHANNEL_OWNER"
+	// ErrCodeChannelNotFound reports a channel that does not exist or was deleted
+	ErrCodeChannelNotFound = "CHANNEL_NOT_FOUND"
+	// ErrCodeChannelVersionNotFound reports a configuration version the channel never had
+	ErrCodeChannelVersionNotFound = "CHANNEL_VERSION_NOT_FOUND"
+	// ErrCodeRestorePeriodExpired reports a deleted channel past its restore grace period
+	ErrCodeRestorePeriodExpired = "RESTORE_PERIOD_EXPIRED"
+	// ErrCodeClientClosedRequest reports a request the client cancelled before the response
+	ErrCodeClientClosedRequest = "CLIENT_CLOSED_REQUEST"
+	// ErrCodeInternal reports a failure of the service itself
+	ErrCodeInternal = "INTERNAL_ERROR"
+	// ErrCodeUpstream reports a failure of the Twitter user-info service
+	ErrCodeUpstream = "UPSTREAM_ERROR"
+	// ErrCodeTimeout reports a database operation or upstream call that timed out
+	ErrCodeTimeout = "TIMEOUT"
+)
@@ -0,0 +1,169 @@
+package models
+
+import (
+	"reflect"
+	"slices"
+)
+
+// ChannelVersion is a recorded configuration of a channel. A version is written whenever an
+// update changes the name, description, visibility, watched accounts, filters or eventlist
+type ChannelVersion struct {
+	ChannelID   string      `json:"channelId" gorm:"primaryKey"`
+	Version     int         `json:"version" gorm:"primaryKey"`
+	AuthorID    int         `json:"authorId"`
+	CreatedAt   int64       `json:"createdAt"`
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	IsPublic    bool        `json:"isPublic"`
+	Watchlist   []Watchlist `json:"watchlist" gorm:"type:jsonb;serializer:json"`
+	Eventlist   []EventList `json:"eventlist" gorm:"type:jsonb;serializer:json"`
+}
+
+// NewChannelVersion snapshots the versioned configuration of a channel; Version is left for the store to assign
+func NewChannelVersion(channel *Channel, authorID int, createdAt int64) *ChannelVersion {
+	return &ChannelVersion{
+		ChannelID:   channel.ID,
+		AuthorID:    authorID,
+		CreatedAt:   createdAt,
+		Name:        channel.Name,
+		Description: channel.Description,
+		IsPublic:    channel.IsPublic,
+		Watchlist:   channel.Watchlist,
+		Eventlist:   channel.Eventlist,
+	}
+}
+
+// ValueChange is a field that changed between two versions
+type ValueChange struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+// WatchFilter is what a channel shows of a watched account, with the CA filter in effective form
+type WatchFilter struct {
+	Tweets        bool         `json:"tweets"`
+	ProfileUpdate bool         `json:"profileUpdate"`
+	Follows       bool         `json:"follows"`
+	FilterCA      CAFilterMode `json:"filterCA"`
+	CAs           []string     `json:"cas"`
+}
+
+// Filter returns the effective filter of a watched account
+func (w Watchlist) Filter() WatchFilter {
+	mode, cas := w.CAFilter()
+	return WatchFilter{
+		Tweets:        w.Tweets,
+		ProfileUpdate: w.ProfileUpdate,
+		Follows:       w.Follows,
+		FilterCA:      mode,
+		CAs:           cas,
+	}
+}
+
+// equal reports whether two filters show the same content
+func (f WatchFilter) equal(other WatchFilter) bool {
+	return f.Tweets == other.Tweets &&
+		f.ProfileUpdate == other.ProfileUpdate &&
+		f.Follows == other.Follows &&
+		f.FilterCA == other.FilterCA &&
+		slices.Equal(f.CAs, other.CAs)
+}
+
+// WatchFilterChange is a watched account whose filter changed between two versions
+type WatchFilterChange struct {
+	TwitterId   string      `json:"twitterId"`
+	TwitterName string      `json:"twitterName"`
+	From        WatchFilter `json:"from"`
+	To          WatchFilter `json:"to"`
+}
+
+// ChannelDiff describes the configuration changes from one channel version to the next
+type ChannelDiff struct {
+	Name             *ValueChange        `json:"name,omitempty"`
+	Description      *ValueChange        `json:"description,omitempty"`
+	IsPublic         *ValueChange        `json:"isPublic,omitempty"`
+	AddedAccounts    []Watchlist         `json:"addedAccounts,omitempty"`
+	RemovedAccounts  []Watchlist         `json:"removedAccounts,omitempty"`
+	ChangedFilters   []WatchFilterChange `json:"changedFilters,omitempty"`
+	EventlistChanged bool                `json:"eventlistChanged,omitempty"`
+}
+
+// Empty reports whether the diff has no changes
+func (d ChannelDiff) Empty() bool {
+	return d.Name == nil && d.Description == nil && d.IsPublic == nil &&
+		len(d.AddedAccounts) == 0 && len(d.RemovedAccounts) == 0 && len(d.ChangedFilters) == 0 &&
+		!d.EventlistChanged
+}
+
+// watchKey identifies a watched account across versions
+func watchKey(w Watchlist) string {
+	if w.TwitterId != "" {
+		return w.TwitterId
+	}
+	return w.TwitterName
+}
+
+// DiffChannelVersions compares two versions of a channel; a nil from is treated as an empty
+// channel. Display fields of watched accounts, like their avatar and risk, are not compared
+func DiffChannelVersions(from, to *ChannelVersion) ChannelDiff {
+	if from == nil {
+		from = &ChannelVersion{}
+	}
+
+	var diff ChannelDiff
+	if from.Name != to.Name {
+		diff.Name = &ValueChange{From: from.Name, To: to.Name}
+	}
+	if from.Description != to.Description {
+		diff.Description = &ValueChange{From: from.Description, To: to.Description}
+	}
+	if from.IsPublic != to.IsPublic {
+		diff.IsPublic = &ValueChange{From: from.IsPublic, To: to.IsPublic}
+	}
+
+	before := make(map[string]Watchlist, len(from.Watchlist))
+	for _, watch := range from.Watchlist {
+		if _, ok := before[watchKey(watch)]; !ok {
+			before[watchKey(watch)] = watch
+		}
+	}
+	after := make(map[string]bool, len(to.Watchlist))
+	for _, watch := range to.Watchlist {
+		key := watchKey(watch)
+		if after[key] {
+			continue
+		}
+		after[key] = true
+
+		previous, ok := before[key]
+		if !ok {
+			diff.AddedAccounts = append(diff.AddedAccounts, watch)
+			continue
+		}
+		if !previous.Filter().equal(watch.Filter()) {
+			diff.ChangedFilters = append(diff.ChangedFilters, WatchFilterChange{
+				TwitterId:   watch.TwitterId,
+				TwitterName: watch.TwitterName,
+				From:        previous.Filter(),
+				To:          watch.Filter(),
+			})
+		}
+	}
+	for _, watch := range from.Watchlist {
+		if key := watchKey(watch); !after[key] {
+			after[key] = true
+			diff.RemovedAccounts = append(diff.RemovedAccounts, watch)
+		}
+	}
+
+	if len(from.Eventlist) != 0 || len(to.Eventlist) != 0 {
+		diff.EventlistChanged = !reflect.DeepEqual(from.Eventlist, to.Eventlist)
+	}
+	return diff
+}
+
+// ChannelHistoryEntry is a channel version with the changes it made to the version before it
+type ChannelHistoryEntry struct {
+	*ChannelVersion
+	Changes ChannelDiff `json:"changes"`
+}
@@ -0,0 +1,97 @@
+package risk
+
+import (
+	"TwitterMonitor/internal/models"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ProfileSnapshot is the part of a profile update record used for scoring
+type ProfileSnapshot struct {
+	UserName       string
+	FollowersCount int
+	FollowingCount int
+	AccountCreated time.Time
+	CapturedAt     time.Time
+}
+
+// Key names used by the crawler and the Twitter API for the same profile fields
+var (
+	userNameKeys  = []string{"screen_name", "screenName", "userName", "username"}
+	followersKeys = []string{"followers_count", "followersCount", "followers"}
+	followingKeys = []string{"friends_count", "following_count", "followingCount", "following"}
+	createdKeys   = []string{"created_at", "createdAt", "accountCreatedAt"}
+)
+
+// parseSnapshot reads a profile snapshot from the JSON content of a profile update record
+func parseSnapshot(info *models.TwitterInfo) (ProfileSnapshot, bool) {
+	var raw map[string]interface{}
+	if err := json.Unmarshal([]byte(info.Content), &raw); err != nil {
+		return ProfileSnapshot{}, false
+	}
+
+	// Profiles may be wrapped as {"user": {...}} or {"data": {...}}
+	for _, key := range []string{"user", "data"} {
+		if inner, ok := raw[key].(map[string]interface{}); ok {
+			raw = inner
+			break
+		}
+	}
+
+	snapshot := ProfileSnapshot{
+		UserName:       lookupString(raw, userNameKeys),
+		FollowersCount: lookupInt(raw, followersKeys),
+		FollowingCount: lookupInt(raw, followingKeys),
+		AccountCreated: parseTime(lookupString(raw, createdKeys)),
+		CapturedAt:     time.UnixMilli(info.CreateTime),
+	}
+	return snapshot, snapshot.UserName != "" || snapshot.FollowersCount > 0 || !snapshot.AccountCreated.IsZero()
+}
+
+func lookupString(raw map[string]interface{}, keys []string) string {
+	for _, key := range keys {
+		switch v := raw[key].(type) {
+		case string:
+			return v
+		case float64:
+			return strconv.FormatFloat(v, 'f', -1, 64)
+		}
+	}
+	return ""
+}
+
+func lookupInt(raw map[string]interface{}, keys []string) int {
+	for _, key := range keys {
+		switch v := raw[key].(type) {
+		case float64:
+			return int(v)
+		case string:
+			if n, err := strconv.Atoi(v); err == nil {
+				return n
+			}
+		}
+	}
+	return 0
+}
+
+// parseTime accepts the Twitter API date format, RFC 3339 and unix seconds or milliseconds
+func parseTime(value string) time.Time {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}
+	}
+	for _, layout := range []string{time.RubyDate, time.RFC3339} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t
+		}
+	}
+	if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+		if n > 1e12 {
+			return time.UnixMilli(n)
+		}
+		return time.Unix(n, 0)
+	}
+	return time.Time{}
+}
@@ -0,0 +1,242 @@
+package risk
+
+import (
+	"TwitterMonitor/internal/database"
+	"TwitterMonitor/internal/models"
+	"context"
+	"fmt"
+	"time"
+)
+
+const (
+	// snapshotLimit is how many recent profile updates are inspected per account
+	snapshotLimit = 200
+	// maxScoreAge is how long a stored score is reused before it is recomputed
+	maxScoreAge = 24 * time.Hour
+	// minTweets is the number of stored tweets needed before tweet-based signals count
+	minTweets = 5
+)
+
+// Scorer computes and stores risk scores of watched Twitter accounts
+type Scorer struct {
+	db database.Store
+}
+
+// NewScorer creates a new risk scorer
+func NewScorer(db database.Store) *Scorer {
+	return &Scorer{db: db}
+}
+
+// Score computes the current risk score of a Twitter account and stores it
+func (s *Scorer) Score(ctx context.Context, twitterId string) (*models.RiskScore, error) {
+	updates, err := s.db.GetProfileUpdates(ctx, twitterId, snapshotLimit)
+	if err != nil {
+		return nil, err
+	}
+	var snapshots []ProfileSnapshot
+	for _, update := range updates {
+		if snapshot, ok := parseSnapshot(update); ok {
+			snapshots = append(snapshots, snapshot)
+		}
+	}
+
+	handles, err := s.db.GetTwitterHandles(ctx, twitterId)
+	if err != nil {
+		return nil, err
+	}
+
+	stats, err := s.db.GetTweetStats(ctx, twitterId)
+	if err != nil {
+		return nil, err
+	}
+
+	score := Compute(twitterId, snapshots, len(handles), *stats, time.Now())
+	if err := s.db.SaveRiskScore(ctx, score); err != nil {
+		return nil, err
+	}
+	return score, nil
+}
+
+// Fill sets the risk fields of each watchlist entry, reusing stored scores that are still fresh
+func (s *Scorer) Fill(ctx context.Context, watchlist []models.Watchlist) error {
+	var twitterIds []string
+	for _, watch := range watchlist {
+		if watch.TwitterId != "" {
+			twitterIds = append(twitterIds, watch.TwitterId)
+		}
+	}
+
+	scores, err := s.db.GetRiskScores(ctx, twitterIds)
+	if err != nil {
+		return err
+	}
+
+	for i := range watchlist {
+		watch := &watchlist[i]
+		if watch.TwitterId == "" {
+			continue
+		}
+		score, ok := scores[watch.TwitterId]
+		if !ok || time.Since(time.UnixMilli(score.UpdatedAt)) > maxScoreAge {
+			if score, err = s.Score(ctx, watch.TwitterId); err != nil {
+				return fmt.Errorf("failed to score %s: %w", watch.TwitterId, err)
+			}
+			scores[watch.TwitterId] = score
+		}
+		watch.Risk = score.Score
+		watch.TwitterRisk = score.Level
+	}
+	return nil
+}
+
+// Compute derives a 0-100 risk score from an account's profile snapshots, the number of
+// handles it was seen with when resolving watchlists, and its tweet statistics
+func Compute(twitterId string, snapshots []ProfileSnapshot, knownHandles int, stats models.TweetStats, now time.Time) *models.RiskScore {
+	factors := []models.RiskFactor{
+		accountAgeFactor(snapshots, now),
+		followerRatioFactor(snapshots),
+		usernameChangeFactor(snapshots, knownHandles),
+		caShillingFactor(stats),
+		deletedTweetFactor(stats),
+	}
+
+	total := 0
+	for _, factor := range factors {
+		total += factor.Points
+	}
+	if total > 100 {
+		total = 100
+	}
+
+	level := models.RiskLevelLow
+	switch {
+	case total >= 60:
+		level = models.RiskLevelHigh
+	case total >= 30:
+		level = models.RiskLevelMedium
+	}
+
+	return &models.RiskScore{
+		TwitterId: twitterId,
+		Score:     total,
+		Level:     level,
+		Factors:   factors,
+		UpdatedAt: now.UnixMilli(),
+	}
+}
+
+// accountAgeFactor scores young accounts, which are cheap to create for one-off promotions
+func accountAgeFactor(snapshots []ProfileSnapshot, now time.Time) models.RiskFactor {
+	factor := models.RiskFactor{Name: "accountAge", Detail: "account creation date unknown"}
+	for _, snapshot := range snapshots {
+		if snapshot.AccountCreated.IsZero() {
+			continue
+		}
+		days := now.Sub(snapshot.AccountCreated).Hours() / 24
+		factor.Value = days
+		factor.Detail = fmt.Sprintf("account is %.0f days old", days)
+		switch {
+		case days < 30:
+			factor.Points = 25
+		case days < 90:
+			factor.Points = 15
+		case days < 365:
+			factor.Points = 5
+		}
+		break
+	}
+	return factor
+}
+
+// followerRatioFactor scores accounts that follow far more accounts than follow them
+func followerRatioFactor(snapshots []ProfileSnapshot) models.RiskFactor {
+	factor := models.RiskFactor{Name: "followerRatio", Detail: "no follower data"}
+	for i := len(snapshots) - 1; i >= 0; i-- {
+		snapshot := snapshots[i]
+		if snapshot.FollowingCount == 0 {
+			continue
+		}
+		ratio := float64(snapshot.FollowersCount) / float64(snapshot.FollowingCount)
+		factor.Value = ratio
+		factor.Detail = fmt.Sprintf("%d followers / %d following", snapshot.FollowersCount, snapshot.FollowingCount)
+		switch {
+		case ratio < 0.1:
+			factor.Points = 20
+		case ratio < 1:
+			factor.Points = 10
+		}
+		break
+	}
+	return factor
+}
+
+// usernameChangeFactor scores accounts that keep changing their handle
+func usernameChangeFactor(snapshots []ProfileSnapshot, knownHandles int) models.RiskFactor {
+	changes := 0
+	previous := ""
+	for _, snapshot := range snapshots {
+		if snapshot.UserName == "" {
+			continue
+		}
+		if previous != "" && snapshot.UserName != previous {
+			changes++
+		}
+		previous = snapshot.UserName
+	}
+	if knownHandles-1 > changes {
+		changes = knownHandles - 1
+	}
+
+	factor := models.RiskFactor{
+		Name:   "usernameChanges",
+		Value:  float64(changes),
+		Detail: fmt.Sprintf("%d username changes", changes),
+	}
+	switch {
+	case changes >= 3:
+		factor.Points = 20
+	case changes >= 1:
+		factor.Points = 10
+	}
+	return factor
+}
+
+// caShillingFactor scores accounts whose tweets mostly promote many different CAs
+func caShillingFactor(stats models.TweetStats) models.RiskFactor {
+	factor := models.RiskFactor{Name: "caShilling", Detail: "not enough tweets"}
+	if stats.Total < minTweets {
+		return factor
+	}
+
+	share := float64(stats.WithCA) / float64(stats.Total)
+	factor.Value = share
+	factor.Detail = fmt.Sprintf("%d of %d tweets mention a CA, %d distinct CAs", stats.WithCA, stats.Total, stats.DistinctCA)
+	switch {
+	case stats.DistinctCA >= 10 && share > 0.5:
+		factor.Points = 25
+	case stats.DistinctCA >= 5 || share > 0.3:
+		factor.Points = 15
+	case stats.DistinctCA >= 2:
+		factor.Points = 5
+	}
+	return factor
+}
+
+// deletedTweetFactor scores accounts that delete a large share of their tweets
+func deletedTweetFactor(stats models.TweetStats) models.RiskFactor {
+	factor := models.RiskFactor{Name: "deletedTweets", Detail: "not enough tweets"}
+	if stats.Total < minTweets {
+		return factor
+	}
+
+	rate := float64(stats.Deleted) / float64(stats.Total)
+	factor.Value = rate
+	factor.Detail = fmt.Sprintf("%d of %d tweets deleted", stats.Deleted, stats.Total)
+	switch {
+	case rate > 0.3:
+		factor.Points = 20
+	case rate > 0.1:
+		factor.Points = 10
+	}
+	return factor
+}
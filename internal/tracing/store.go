@@ -0,0 +1,33 @@
+package tracing
+
+import (
+	"TwitterMonitor/internal/database"
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TraceStore records a span for every method of db, named after the method and nested in
+// the span of the caller's context
+func TraceStore(db database.Store) database.Store {
+	system := semconv.DBSystemKey.String("memory")
+	switch unwrapped := database.Unwrap(db).(type) {
+	case *database.Database:
+		system = semconv.DBSystemKey.String(unwrapped.Dialect())
+	case *database.PostgresDatabase:
+		system = semconv.DBSystemPostgreSQL
+	}
+	return database.WithHook(db, storeHook(system))
+}
+
+// storeHook runs each store operation in a client span tagged with the database system
+func storeHook(system attribute.KeyValue) database.Hook {
+	return func(ctx context.Context, op string, write bool, call func(context.Context) error) (err error) {
+		ctx, span := tracer.Start(ctx, "db."+op, trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(system, semconv.DBOperationName(op)))
+		defer End(span, &err)
+		return call(ctx)
+	}
+}
@@ -0,0 +1,176 @@
+package twitter
+
+import (
+	"TwitterMonitor/internal/metrics"
+	"TwitterMonitor/internal/models"
+	"TwitterMonitor/internal/tracing"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// ErrUserNotFound is returned when the user-info service does not know the requested user
+var ErrUserNotFound = errors.New("twitter user not found")
+
+// ErrUpstream is wrapped by the errors of requests the user-info service failed to answer,
+// like an outage, a rejected token or rate limiting
+var ErrUpstream = errors.New("twitter user-info service failed")
+
+// Client calls the Twitter user-info service
+type Client struct {
+	mu         sync.RWMutex
+	baseURL    string
+	token      string
+	httpClient *http.Client
+}
+
+// NewClient creates a new user-info client for the service at baseURL
+func NewClient(baseURL, token string, timeout time.Duration) *Client {
+	c := &Client{}
+	c.Configure(baseURL, token, timeout)
+	return c
+}
+
+// Configure points the client at another service; requests already sent are not affected
+func (c *Client) Configure(baseURL, token string, timeout time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.baseURL = strings.TrimSuffix(baseURL, "/")
+	c.token = token
+	c.httpClient = &http.Client{Timeout: timeout, Transport: metrics.Transport("twitter_user_info", tracing.Transport("twitter_user_info", nil))}
+}
+
+// RawUserInfo returns the user-info response for a user as decoded JSON
+func (c *Client) RawUserInfo(ctx context.Context, user string) (_ interface{}, err error) {
+	ctx, span := tracing.Start(ctx, "twitter.RawUserInfo", attribute.String("user", user))
+	defer tracing.End(span, &err)
+
+	c.mu.RLock()
+	token := url.QueryEscape(c.token)
+	endpoint := fmt.Sprintf("%s/tw_user_info?user=%s&token=%s", c.baseURL, url.QueryEscape(user), token)
+	httpClient := c.httpClient
+	c.mu.RUnlock()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := httpClient.Do(req)
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		// Keep the token out of error messages, which end up in the logs
+		urlErr.URL = strings.Replace(urlErr.URL, "token="+token, "token=xxxxx", 1)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrUserNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: %s", ErrUpstream, resp.Status)
+	}
+
+	// Keep numbers as json.Number so 64-bit user IDs survive decoding
+	var result interface{}
+	decoder := json.NewDecoder(resp.Body)
+	decoder.UseNumber()
+	if err := decoder.Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode user info: %v", err)
+	}
+	return result, nil
+}
+
+// LookupUser resolves a username to the canonical Twitter user
+func (c *Client) LookupUser(ctx context.Context, user string) (*models.TwitterUser, error) {
+	result, err := c.RawUserInfo(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	raw, ok := result.(map[string]interface{})
+	if !ok {
+		return nil, ErrUserNotFound
+	}
+	fields := flatten(raw)
+
+	twitterUser := &models.TwitterUser{
+		ID:             lookupString(fields, "rest_id", "id_str", "id", "user_id", "userId"),
+		UserName:       lookupString(fields, "screen_name", "username", "userName"),
+		DisplayName:    lookupString(fields, "name", "displayName"),
+		Avatar:         lookupString(fields, "profile_image_url_https", "profile_image_url", "avatar"),
+		Verified:       lookupBool(fields, "verified", "is_blue_verified", "isVerified"),
+		FollowersCount: lookupInt(fields, "followers_count", "followersCount"),
+		FollowingCount: lookupInt(fields, "friends_count", "following_count", "followingCount"),
+		CreatedAt:      lookupString(fields, "created_at", "createdAt"),
+	}
+	if twitterUser.ID == "" {
+		return nil, ErrUserNotFound
+	}
+	return twitterUser, nil
+}
+
+// flatten merges the fields of a user-info response, which may nest the user under
+// data/user/result/legacy depending on the upstream API version. Outer fields win
+func flatten(raw map[string]interface{}) map[string]interface{} {
+	fields := make(map[string]interface{})
+	var walk func(m map[string]interface{})
+	walk = func(m map[string]interface{}) {
+		for key, value := range m {
+			if _, ok := fields[key]; !ok {
+				fields[key] = value
+			}
+		}
+		for _, key := range []string{"data", "user", "result", "legacy"} {
+			if inner, ok := m[key].(map[string]interface{}); ok {
+				walk(inner)
+			}
+		}
+	}
+	walk(raw)
+	return fields
+}
+
+func lookupString(fields map[string]interface{}, keys ...string) string {
+	for _, key := range keys {
+		switch v := fields[key].(type) {
+		case string:
+			if v != "" {
+				return v
+			}
+		case json.Number:
+			return v.String()
+		}
+	}
+	return ""
+}
+
+func lookupInt(fields map[string]interface{}, keys ...string) int {
+	for _, key := range keys {
+		if v, ok := fields[key].(json.Number); ok {
+			if n, err := v.Int64(); err == nil {
+				return int(n)
+			}
+		}
+	}
+	return 0
+}
+
+func lookupBool(fields map[string]interface{}, keys ...string) bool {
+	for _, key := range keys {
+		if v, ok := fields[key].(bool); ok && v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,151 @@
+package twitter
+
+import (
+	"TwitterMonitor/internal/database"
+	"TwitterMonitor/internal/models"
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// UnknownUserError reports a watchlist entry that does not resolve to a Twitter user
+type UnknownUserError struct {
+	User string
+}
+
+func (e *UnknownUserError) Error() string {
+	return fmt.Sprintf("twitter user not found: %s", e.User)
+}
+
+// Resolver fills watchlist entries with canonical account data from the user-info service
+type Resolver struct {
+	client *Client
+	db     database.Store
+}
+
+// NewResolver creates a new watchlist resolver
+func NewResolver(client *Client, db database.Store) *Resolver {
+	return &Resolver{client: client, db: db}
+}
+
+// Resolve replaces the ID, name, avatar and verified status of every watchlist entry with
+// the canonical values, returning an *UnknownUserError for entries that match no account
+func (r *Resolver) Resolve(ctx context.Context, watchlist []models.Watchlist) error {
+	now := time.Now().UnixMilli()
+	for i := range watchlist {
+		watch := &watchlist[i]
+		user, err := r.resolve(ctx, *watch)
+		if err != nil {
+			return err
+		}
+
+		if err := r.db.RecordTwitterHandle(ctx, user.ID, user.UserName, now); err != nil {
+			return err
+		}
+		if err := r.db.UpsertTwitterAccount(ctx, AccountFromUser(user, now)); err != nil {
+			return err
+		}
+
+		watch.TwitterId = user.ID
+		watch.TwitterName = user.UserName
+		watch.TwitterAvatar = user.Avatar
+		watch.TwitterVerify = strconv.FormatBool(user.Verified)
+	}
+	return nil
+}
+
+// resolve looks an entry up by name. When the name is unknown or now belongs to a different
+// account than the entry's ID, the account may have been renamed, so it is looked up by ID and
+// then by its other known handles. Only when all of these fail is the entry moved to the
+// account now holding the name
+func (r *Resolver) resolve(ctx context.Context, watch models.Watchlist) (*models.TwitterUser, error) {
+	user, err := r.lookup(ctx, watch.TwitterName)
+	if err != nil {
+		return nil, err
+	}
+	if user != nil && (watch.TwitterId == "" || user.ID == watch.TwitterId) {
+		return user, nil
+	}
+
+	if watch.TwitterId != "" {
+		byId, err := r.lookup(ctx, watch.TwitterId)
+		if err != nil {
+			return nil, err
+		}
+		if byId != nil && byId.ID == watch.TwitterId {
+			return byId, nil
+		}
+
+		renamed, err := r.renamed(ctx, watch.TwitterId, watch.TwitterName)
+		if err != nil {
+			return nil, err
+		}
+		if renamed != nil {
+			return renamed, nil
+		}
+	}
+
+	// The name resolved but the client's ID was wrong
+	if user != nil {
+		return user, nil
+	}
+
+	name := watch.TwitterName
+	if name == "" {
+		name = watch.TwitterId
+	}
+	return nil, &UnknownUserError{User: name}
+}
+
+// renamed looks up the most recent other handle recorded for twitterId and returns the
+// account if that handle still belongs to it
+func (r *Resolver) renamed(ctx context.Context, twitterId, name string) (*models.TwitterUser, error) {
+	handles, err := r.db.GetTwitterHandles(ctx, twitterId)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, handle := range handles {
+		if strings.EqualFold(handle.UserName, name) {
+			continue
+		}
+		user, err := r.lookup(ctx, handle.UserName)
+		if err != nil || user == nil || user.ID != twitterId {
+			return nil, err
+		}
+		return user, nil
+	}
+	return nil, nil
+}
+
+// lookup returns nil without an error when the user does not exist
+func (r *Resolver) lookup(ctx context.Context, name string) (*models.TwitterUser, error) {
+	if name == "" {
+		return nil, nil
+	}
+	user, err := r.client.LookupUser(ctx, name)
+	if errors.Is(err, ErrUserNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up Twitter user %s: %w", name, err)
+	}
+	return user, nil
+}
+
+// AccountFromUser converts a user-info result to a cached account record
+func AccountFromUser(user *models.TwitterUser, refreshedAt int64) *models.TwitterAccount {
+	return &models.TwitterAccount{
+		TwitterId:      user.ID,
+		UserName:       user.UserName,
+		DisplayName:    user.DisplayName,
+		Avatar:         user.Avatar,
+		Verified:       user.Verified,
+		FollowersCount: user.FollowersCount,
+		FollowingCount: user.FollowingCount,
+		RefreshedAt:    refreshedAt,
+	}
+}
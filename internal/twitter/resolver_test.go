@@ -0,0 +1,133 @@
+package twitter
+
+import (
+	"TwitterMonitor/internal/database"
+	"TwitterMonitor/internal/models"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// newTestResolver serves the user-info API for alice (ID 1, formerly alice_old and alice_2020)
+// and carol (ID 2, who took alice_2020), and fails with 500 for down and 429 for busy; every
+// other user is unknown
+func newTestResolver(t *testing.T) (*Resolver, database.Store) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("token") != "secret" {
+			http.Error(w, "bad token", http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Query().Get("user") {
+		case "alice", "1":
+			fmt.Fprint(w, `{"data":{"user":{"result":{"rest_id":"1","legacy":{"screen_name":"alice","name":"Alice","verified":true,"followers_count":42}}}}}`)
+		case "carol", "alice_2020", "2":
+			fmt.Fprint(w, `{"data":{"user":{"result":{"rest_id":"2","legacy":{"screen_name":"carol","name":"Carol"}}}}}`)
+		case "down":
+			http.Error(w, "upstream exploded", http.StatusInternalServerError)
+		case "busy":
+			http.Error(w, "slow down", http.StatusTooManyRequests)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	db := database.NewMemoryDatabase()
+	client := NewClient(server.URL, "secret", time.Second)
+	return NewResolver(client, db), db
+}
+
+func TestResolve(t *testing.T) {
+	resolver, db := newTestResolver(t)
+	ctx := context.Background()
+
+	watchlist := []models.Watchlist{{TwitterName: "alice"}}
+	if err := resolver.Resolve(ctx, watchlist); err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	watch := watchlist[0]
+	if watch.TwitterId != "1" || watch.TwitterName != "alice" || watch.TwitterVerify != "true" {
+		t.Errorf("resolved entry = %+v", watch)
+	}
+
+	accounts, err := db.GetTwitterAccounts(ctx, []string{"1"})
+	if err != nil {
+		t.Fatalf("GetTwitterAccounts: %v", err)
+	}
+	if account := accounts["1"]; account == nil || account.FollowersCount != 42 {
+		t.Errorf("cached account = %+v", account)
+	}
+}
+
+func TestResolveRenamed(t *testing.T) {
+	tests := []struct {
+		name string
+		// old is the handle the entry was saved with
+		old     string
+		handles []string
+	}{
+		{"new handle recorded", "alice_old", []string{"alice_old", "alice"}},
+		{"only old handle recorded", "alice_old", []string{"alice_old"}},
+		{"old handle re-registered", "alice_2020", []string{"alice_2020"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resolver, db := newTestResolver(t)
+			ctx := context.Background()
+
+			for i, handle := range tt.handles {
+				if err := db.RecordTwitterHandle(ctx, "1", handle, int64(1000+i)); err != nil {
+					t.Fatalf("RecordTwitterHandle: %v", err)
+				}
+			}
+			watchlist := []models.Watchlist{{TwitterId: "1", TwitterName: tt.old}}
+			if err := resolver.Resolve(ctx, watchlist); err != nil {
+				t.Fatalf("Resolve: %v", err)
+			}
+			if watch := watchlist[0]; watch.TwitterId != "1" || watch.TwitterName != "alice" {
+				t.Errorf("renamed entry resolved to %s/%s, want 1/alice", watch.TwitterId, watch.TwitterName)
+			}
+		})
+	}
+}
+
+func TestResolveUnknownUser(t *testing.T) {
+	resolver, _ := newTestResolver(t)
+
+	err := resolver.Resolve(context.Background(), []models.Watchlist{{TwitterName: "ghost"}})
+	var unknown *UnknownUserError
+	if !errors.As(err, &unknown) || unknown.User != "ghost" {
+		t.Fatalf("Resolve = %v, want an UnknownUserError for ghost", err)
+	}
+}
+
+func TestResolveUpstreamFailure(t *testing.T) {
+	for _, name := range []string{"down", "busy"} {
+		t.Run(name, func(t *testing.T) {
+			resolver, _ := newTestResolver(t)
+
+			err := resolver.Resolve(context.Background(), []models.Watchlist{{TwitterName: name}})
+			var unknown *UnknownUserError
+			if errors.As(err, &unknown) {
+				t.Fatalf("Resolve = %v, an upstream failure must not report an unknown user", err)
+			}
+			if !errors.Is(err, ErrUpstream) {
+				t.Fatalf("Resolve = %v, want ErrUpstream", err)
+			}
+		})
+	}
+}
+
+func TestRawUserInfoRejectedToken(t *testing.T) {
+	resolver, _ := newTestResolver(t)
+	resolver.client.Configure(resolver.client.baseURL, "wrong", time.Second)
+
+	_, err := resolver.client.RawUserInfo(context.Background(), "alice")
+	if !errors.Is(err, ErrUpstream) {
+		t.Fatalf("RawUserInfo = %v, want ErrUpstream", err)
+	}
+}
@@ -1,19 +1,100 @@
 package utils
 
 import (
+	"context"
 	"fmt"
-	"log"
+	"io"
+	"log/slog"
 	"runtime"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Log formats
+const (
+	LogFormatText = "text"
+	LogFormatJSON = "json"
 )
 
-// LogError 自定义日志函数，支持和 log.Fatalf 一样的入参格式
-func LogError(format string, v ...interface{}) {
-	// 获取调用者信息
-	_, file, line, ok := runtime.Caller(1)
-	err := fmt.Errorf(format, v...)
-	if ok {
-		log.Printf("[ERROR] %s:%d - %v", file, line, err)
-	} else {
-		log.Printf("[ERROR] Unable to get caller information - %v", err)
+// logLevel is the lowest level logged; it can be changed while the server runs
+var logLevel = new(slog.LevelVar)
+
+var levelNames = map[string]slog.Level{
+	"debug": slog.LevelDebug,
+	"info":  slog.LevelInfo,
+	"warn":  slog.LevelWarn,
+	"error": slog.LevelError,
+}
+
+// ParseLogLevel converts a level name (debug, info, warn or error) to its level
+func ParseLogLevel(name string) (slog.Level, error) {
+	if level, ok := levelNames[strings.ToLower(name)]; ok {
+		return level, nil
+	}
+	return 0, fmt.Errorf("unknown log level %q, expected debug, info, warn or error", name)
+}
+
+// SetLogLevel sets the lowest level that is logged
+func SetLogLevel(level slog.Level) {
+	logLevel.Set(level)
+}
+
+// SetupLogger makes slog's default logger, which the log package also writes through, emit
+// text or JSON records to w. Records carry the request ID and trace ID of their context, and
+// errors the source line that logged them
+func SetupLogger(w io.Writer, format string) error {
+	options := &slog.HandlerOptions{Level: logLevel}
+	var handler slog.Handler
+	switch format {
+	case LogFormatText:
+		handler = slog.NewTextHandler(w, options)
+	case LogFormatJSON:
+		handler = slog.NewJSONHandler(w, options)
+	default:
+		return fmt.Errorf("unknown log format %q, expected %s or %s", format, LogFormatText, LogFormatJSON)
 	}
+	slog.SetDefault(slog.New(&contextHandler{Handler: handler}))
+	return nil
+}
+
+type requestIDKey struct{}
+
+// WithRequestID returns a context whose log records carry the request ID id
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the request ID of ctx, or an empty string
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// contextHandler adds the request ID and trace ID of the record's context and, for errors, the
+// source line
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h *contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := RequestID(ctx); id != "" {
+		r.AddAttrs(slog.String("requestId", id))
+	}
+	if span := trace.SpanContextFromContext(ctx); span.HasTraceID() {
+		r.AddAttrs(slog.String("traceId", span.TraceID().String()))
+	}
+	if r.Level >= slog.LevelError && r.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+		r.AddAttrs(slog.String("source", fmt.Sprintf("%s:%d", frame.File, frame.Line)))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithGroup(name)}
 }
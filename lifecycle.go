@@ -0,0 +1,128 @@
+package main
+
+import (
+	"TwitterMonitor/internal/database"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// worker is a background job running until its context is cancelled
+type worker struct {
+	name   string
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// lifecycle runs the HTTP server and the background workers, and on SIGINT or SIGTERM stops
+// them in order: the server drains its in-flight requests, the workers are stopped in reverse
+// start order, the database is closed and the remaining spans are flushed last, all within
+// shutdownTimeout
+type lifecycle struct {
+	server          *http.Server
+	db              database.Store
+	flushTraces     func(ctx context.Context) error
+	shutdownTimeout time.Duration
+	workers         []*worker
+}
+
+// newLifecycle creates a lifecycle owning db and the trace exporter flushed by flushTraces
+func newLifecycle(db database.Store, flushTraces func(ctx context.Context) error, shutdownTimeout time.Duration) *lifecycle {
+	return &lifecycle{db: db, flushTraces: flushTraces, shutdownTimeout: shutdownTimeout}
+}
+
+// startWorker runs a background job until shutdown
+func (l *lifecycle) startWorker(name string, run func(ctx context.Context)) {
+	ctx, cancel := context.WithCancel(context.Background())
+	w := &worker{name: name, cancel: cancel, done: make(chan struct{})}
+	go func() {
+		defer close(w.done)
+		run(ctx)
+	}()
+	l.workers = append(l.workers, w)
+	slog.Info("Worker started", "worker", name)
+}
+
+// workerStatus reports whether each background worker is still running
+func (l *lifecycle) workerStatus() map[string]bool {
+	status := make(map[string]bool, len(l.workers))
+	for _, w := range l.workers {
+		select {
+		case <-w.done:
+			status[w.name] = false
+		default:
+			status[w.name] = true
+		}
+	}
+	return status
+}
+
+// run serves handler on addr until a termination signal arrives or the server fails, then
+// shuts down
+func (l *lifecycle) run(addr string, handler http.Handler) error {
+	l.server = &http.Server{Addr: addr, Handler: handler}
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return errors.Join(fmt.Errorf("failed to start server: %v", err), l.shutdown())
+	}
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- l.server.Serve(listener)
+	}()
+	slog.Info("Server listening", "addr", addr)
+
+	select {
+	case err = <-serverErr:
+		err = fmt.Errorf("server failed: %v", err)
+	case <-ctx.Done():
+		slog.Info("Shutting down")
+	}
+	// A second signal kills the process without waiting
+	stop()
+
+	return errors.Join(err, l.shutdown())
+}
+
+// shutdown stops the server, the workers, the database and the trace exporter, reporting what did not stop cleanly
+func (l *lifecycle) shutdown() error {
+	ctx, cancel := context.WithTimeout(context.Background(), l.shutdownTimeout)
+	defer cancel()
+
+	var errs []error
+	if err := l.server.Shutdown(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("failed to drain HTTP requests: %v", err))
+	} else {
+		slog.Info("HTTP server stopped")
+	}
+
+	for i := len(l.workers) - 1; i >= 0; i-- {
+		w := l.workers[i]
+		w.cancel()
+		select {
+		case <-w.done:
+			slog.Info("Worker stopped", "worker", w.name)
+		case <-ctx.Done():
+			errs = append(errs, fmt.Errorf("%s did not stop within %s", w.name, l.shutdownTimeout))
+		}
+	}
+
+	if err := l.db.Close(); err != nil {
+		errs = append(errs, fmt.Errorf("failed to close database: %v", err))
+	} else {
+		slog.Info("Database closed")
+	}
+
+	if err := l.flushTraces(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("failed to flush traces: %v", err))
+	}
+	return errors.Join(errs...)
+}
@@ -4,50 +4,158 @@ import (
 	"TwitterMonitor/config"
 	"TwitterMonitor/internal/database"
 	"TwitterMonitor/internal/handlers"
+	"TwitterMonitor/internal/jobs"
+	"TwitterMonitor/internal/metrics"
+	"TwitterMonitor/internal/tracing"
+	"TwitterMonitor/internal/twitter"
+	"TwitterMonitor/internal/utils"
+	"context"
+	"errors"
 	"fmt"
 	"log"
+	"log/slog"
+	"os"
 
 	"github.com/gin-gonic/gin"
+	"github.com/spf13/pflag"
 )
 
 func main() {
-	log.Println("Starting the application...")
-	cfg := config.LoadConfig()
-	log.Println("Config loaded:", cfg)
+	live, args, err := config.LoadLive(os.Args[1:])
+	if errors.Is(err, pflag.ErrHelp) {
+		os.Exit(0)
+	}
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+	cfg := live.Get()
+	if err := utils.SetupLogger(os.Stderr, cfg.Log.Format); err != nil {
+		log.Fatalf("Failed to set up logging: %v", err)
+	}
+	applyLogLevel(cfg)
+	slog.Info("Config loaded", "config", cfg)
+
+	if len(args) > 0 {
+		switch args[0] {
+		case "migrate":
+			os.Exit(runMigrate(cfg, args[1:]))
+		case "reconcile":
+			os.Exit(runReconcile(cfg, args[1:]))
+		}
+	}
+
+	flushTraces, err := tracing.Setup(context.Background(), cfg)
+	if err != nil {
+		fatal("Failed to set up tracing", err)
+	}
 
-	db, err := database.NewDatabase(cfg.DatabaseURL)
+	db, err := openDatabase(cfg)
 	if err != nil {
-		log.Fatalf("Failed to connect to MySQL: %v", err)
+		fatal("Failed to connect to database", err)
+	}
+	slog.Info("Database connected")
+
+	// Refuse to serve against a schema that is behind this binary's migrations
+	if err := database.CheckSchema(db); err != nil {
+		fatal("Database schema check failed", err)
 	}
-	log.Println("Database connected successfully")
+	db = database.WithTimeouts(db, func() database.Timeouts {
+		cfg := live.Get().Database
+		return database.Timeouts{Read: cfg.ReadTimeout, Write: cfg.WriteTimeout}
+	})
+	db = metrics.InstrumentStore(tracing.TraceStore(db))
+
+	// Apply reloadable settings when the config file changes
+	twitterClient := twitter.NewClient(cfg.Twitter.BaseURL, cfg.Twitter.Token, cfg.Twitter.Timeout)
+	live.OnReload(applyLogLevel)
+	live.OnReload(func(cfg *config.Config) {
+		twitterClient.Configure(cfg.Twitter.BaseURL, cfg.Twitter.Token, cfg.Twitter.Timeout)
+	})
+	live.Watch()
+
+	// Start background jobs; they are stopped in reverse order on shutdown
+	app := newLifecycle(db, flushTraces, cfg.Server.ShutdownTimeout)
+	app.startWorker("Account refresher", jobs.NewAccountRefresher(db, twitterClient).Run)
+	app.startWorker("Follower reconciler", jobs.NewFollowerReconciler(db, cfg.Jobs.ReconcileFix, cfg.Channels.RecentFollowersLimit).Run)
+	app.startWorker("Channel purger", jobs.NewChannelPurger(db, live).Run)
+	app.startWorker("Stats collector", jobs.NewStatsCollector(db).Run)
 
 	// Initialize handlers
-	channelHandler := handlers.NewChannelHandler(db)
-	log.Println("Channel handler initialized")
+	channelHandler := handlers.NewChannelHandler(db, twitterClient, live)
+	notificationHandler := handlers.NewNotificationHandler(db, live)
+	twitterHandler := handlers.NewTwitterHandler(db)
+	adminHandler := handlers.NewAdminHandler(live)
+	healthHandler := handlers.NewHealthHandler(db, live, app.workerStatus)
 
-	// Initialize Gin router
-	router := gin.Default()
-	log.Println("Gin router initialized")
+	// Initialize Gin router; the request span and ID come first so that every later log line
+	// carries them
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(tracing.Middleware(), handlers.RequestID(), handlers.AccessLog(), handlers.Recovery(), metrics.Middleware())
 
-	// API routes
-	api := router.Group("/v1")
-	{
+	// API routes. /v1 keeps the response format each endpoint always had, /v2 answers every
+	// endpoint with models.APIResponse and stable error codes
+	for version := 1; version <= 2; version++ {
+		api := router.Group(fmt.Sprintf("/v%d", version), handlers.APIVersion(version))
 		channel := api.Group("/channel")
 		{
 			channel.POST("/create", channelHandler.CreateChannel)
 			channel.POST("/update", channelHandler.UpdateChannel)
 			channel.POST("/delete", channelHandler.DeleteChannel)
+			channel.POST("/restore", channelHandler.RestoreChannel)
+			channel.POST("/rollback", channelHandler.RollbackChannel)
 			channel.POST("/follow", channelHandler.FollowChannel)
 			channel.POST("/unfollow", channelHandler.UnfollowChannel)
 			channel.GET("/channel_list", channelHandler.GetChannelList)
 			channel.GET("/channel_content", channelHandler.GetChannelContent)
 			channel.GET("/twitter_info", channelHandler.TwitterInfo)
+			channel.GET("/history", channelHandler.GetChannelHistory)
+		}
+
+		notification := api.Group("/notification")
+		{
+			notification.GET("/list", notificationHandler.GetNotifications)
+		}
+
+		twitter := api.Group("/twitter")
+		{
+			// Only the crawler writes Twitter info
+			twitter.POST("/ingest", handlers.IngestAuth(live), twitterHandler.IngestTwitterInfo)
+			twitter.POST("/delete", handlers.IngestAuth(live), twitterHandler.DeleteTwitterInfo)
+			twitter.GET("/risk", twitterHandler.GetRisk)
 		}
 	}
 
-	// Start server
-	serverAddr := fmt.Sprintf(":%d", cfg.ServerPort)
-	if err := router.Run(serverAddr); err != nil {
-		log.Fatalf("Failed to start server: %v", err)
+	router.GET("/metrics", gin.WrapH(metrics.Handler()))
+	router.GET("/healthz", healthHandler.Healthz)
+	router.GET("/readyz", healthHandler.Readyz)
+
+	admin := router.Group("/admin", handlers.AdminAuth(live))
+	{
+		admin.GET("/config", adminHandler.GetConfig)
 	}
+
+	// Serve until SIGINT or SIGTERM, then drain requests, stop the jobs, close the database and
+	// flush the traces
+	if err := app.run(fmt.Sprintf(":%d", cfg.Server.Port), router); err != nil {
+		fatal("Server stopped with errors", err)
+	}
+	slog.Info("Server stopped")
+}
+
+// openDatabase opens the store configured in cfg
+func openDatabase(cfg *config.Config) (database.Store, error) {
+	return database.Open(cfg.Database.URL, database.Options{RecentFollowersLimit: cfg.Channels.RecentFollowersLimit})
+}
+
+// applyLogLevel sets the log level of cfg; the level was validated when cfg was loaded
+func applyLogLevel(cfg *config.Config) {
+	level, _ := utils.ParseLogLevel(cfg.Log.Level)
+	utils.SetLogLevel(level)
+}
+
+// fatal logs an error that prevents the server from running and exits
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
 }
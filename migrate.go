@@ -0,0 +1,108 @@
+package main
+
+import (
+	"TwitterMonitor/config"
+	"TwitterMonitor/internal/database"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+const migrateUsage = "usage: TwitterMonitor migrate up|down [steps]|status|force <version>"
+
+// runMigrate implements the migrate subcommand and returns the process exit code.
+//
+// A migration that fails part way on MySQL, which commits DDL statement by statement, leaves
+// the schema dirty and further migrations are refused. To recover, finish or undo the failed
+// migration by hand so the schema matches a version, then record it with "migrate force
+// <version>" and carry on with "migrate up"
+func runMigrate(cfg *config.Config, args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		return 2
+	}
+
+	db, err := openDatabase(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to connect to database: %v\n", err)
+		return 1
+	}
+	defer db.Close()
+	migratable, ok := db.(database.Migratable)
+	if !ok {
+		fmt.Fprintln(os.Stderr, "The configured database has no schema to migrate")
+		return 1
+	}
+	migrator, err := migratable.Migrator()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load migrations: %v\n", err)
+		return 1
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up()
+		for _, migration := range applied {
+			fmt.Printf("applied %04d_%s\n", migration.Version, migration.Name)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		if len(applied) == 0 {
+			fmt.Println("schema is up to date")
+		}
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps < 1 {
+				fmt.Fprintln(os.Stderr, migrateUsage)
+				return 2
+			}
+		}
+		reverted, err := migrator.Down(steps)
+		for _, migration := range reverted {
+			fmt.Printf("reverted %04d_%s\n", migration.Version, migration.Name)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+	case "status":
+		statuses, err := migrator.Status()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		for _, status := range statuses {
+			state := "pending"
+			if status.Applied {
+				state = "applied " + time.UnixMilli(status.AppliedAt).Format(time.RFC3339)
+			}
+			if status.Dirty {
+				state += ", dirty"
+			}
+			fmt.Printf("%04d_%s\t%s\n", status.Version, status.Name, state)
+		}
+	case "force":
+		if len(args) != 2 {
+			fmt.Fprintln(os.Stderr, migrateUsage)
+			return 2
+		}
+		version, err := strconv.Atoi(args[1])
+		if err != nil || version < 0 {
+			fmt.Fprintln(os.Stderr, migrateUsage)
+			return 2
+		}
+		if err := migrator.Force(version); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		fmt.Printf("schema recorded at version %04d\n", version)
+	default:
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		return 2
+	}
+	return 0
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"TwitterMonitor/config"
+	"TwitterMonitor/internal/database"
+	"TwitterMonitor/internal/jobs"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// runReconcile implements the reconcile subcommand and returns the process exit code
+func runReconcile(cfg *config.Config, args []string) int {
+	flags := flag.NewFlagSet("reconcile", flag.ContinueOnError)
+	fix := flags.Bool("fix", false, "repair channels whose follower data drifted")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	db, err := openDatabase(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to connect to database: %v\n", err)
+		return 1
+	}
+	defer db.Close()
+	if err := database.CheckSchema(db); err != nil {
+		fmt.Fprintf(os.Stderr, "Database schema check failed: %v\n", err)
+		return 1
+	}
+
+	// Stop between pages on Ctrl-C rather than in the middle of a repair
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	discrepancies, err := jobs.NewFollowerReconciler(db, *fix, cfg.Channels.RecentFollowersLimit).ReconcileOnce(ctx)
+	for _, d := range discrepancies {
+		status := "drifted"
+		if d.Fixed {
+			status = "fixed"
+		}
+		fmt.Printf("%s\t%s\tcount %d -> %d\trecent %v -> %v\n",
+			d.ChannelID, status, d.StoredCount, d.ActualCount, d.StoredRecent, d.ActualRecent)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	fmt.Printf("%d channels drifted\n", len(discrepancies))
+	if !*fix && len(discrepancies) > 0 {
+		fmt.Println("run with -fix to repair them")
+	}
+	return 0
+}